@@ -0,0 +1,194 @@
+package mredis
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const AutoPipelineBatchDefault = 128
+
+// 依赖连接状态或者会长时间占用连接的命令不能合并
+var unbatchableCommands = map[string]bool{
+	"MULTI":      true,
+	"EXEC":       true,
+	"DISCARD":    true,
+	"WATCH":      true,
+	"UNWATCH":    true,
+	"SELECT":     true,
+	"AUTH":       true,
+	"HELLO":      true,
+	"CLIENT":     true,
+	"RESET":      true,
+	"QUIT":       true,
+	"MONITOR":    true,
+	"SUBSCRIBE":  true,
+	"PSUBSCRIBE": true,
+	"SSUBSCRIBE": true,
+}
+
+func isBatchable(cmd string) bool {
+	return !unbatchableCommands[strings.ToUpper(cmd)] && !isBlocking(cmd)
+}
+
+/*
+	自动 pipeline: 并发调用的单条命令在 window 时间内(或者攒够 maxBatch 条)合并,
+	在一条连接上一次 Flush 发出, 再按顺序把结果分发给各个调用方;
+	适合大量 goroutine 并发执行 Get/HGet 等简单命令的场景, 单个请求的延迟最多增加 window
+
+	MULTI/WATCH/SELECT 等依赖连接状态的命令和 BLPOP 等阻塞命令不合并;
+	Pipeline、Tx、Watch 使用独立连接, 不受影响
+*/
+func WithAutoPipeline(window time.Duration, maxBatch int) Option {
+	return func(opt *poolOption) {
+		opt.AutoPipelineWindow = window
+		opt.AutoPipelineBatch = maxBatch
+	}
+}
+
+type batchReq struct {
+	ctx   context.Context
+	cmd   string
+	args  []interface{}
+	value interface{}
+	err   error
+	done  chan struct{}
+}
+
+type autoPipeline struct {
+	rp       *RedisPool
+	window   time.Duration
+	maxBatch int
+
+	reqs     chan *batchReq
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newAutoPipeline(rp *RedisPool, window time.Duration, maxBatch int) *autoPipeline {
+	if maxBatch <= 0 {
+		maxBatch = AutoPipelineBatchDefault
+	}
+
+	return &autoPipeline{
+		rp:       rp,
+		window:   window,
+		maxBatch: maxBatch,
+		reqs:     make(chan *batchReq, maxBatch),
+		stop:     make(chan struct{}),
+	}
+}
+
+func (p *autoPipeline) do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	req := &batchReq{ctx: ctx, cmd: cmd, args: args, done: make(chan struct{})}
+	select {
+	case p.reqs <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.stop:
+		return nil, ErrPoolShutdown
+	}
+
+	// ctx 结束时不再等待, 命令仍然会随所在批次执行
+	select {
+	case <-req.done:
+		return req.value, req.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.stop:
+		return nil, ErrPoolShutdown
+	}
+}
+
+// 收集一批命令后交给 flush, 多个批次可以同时在不同连接上执行
+func (p *autoPipeline) run() {
+	for {
+		var first *batchReq
+		select {
+		case first = <-p.reqs:
+		case <-p.stop:
+			return
+		}
+
+		batch := []*batchReq{first}
+		timer := time.NewTimer(p.window)
+	collect:
+		for len(batch) < p.maxBatch {
+			select {
+			case r := <-p.reqs:
+				batch = append(batch, r)
+			case <-timer.C:
+				break collect
+			case <-p.stop:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		go p.flush(batch)
+	}
+}
+
+/*
+	取连接和读回复都受批次 ctx 限制, 所有调用方都放弃等待后取消; 读回复同时受连接的 readTimeout 限制,
+	出错时这一批剩下的请求都返回该错误, ctx 取消后连接被标记为不可用, 归还时关闭
+*/
+func (p *autoPipeline) flush(batch []*batchReq) {
+	ctx, cancel := batchContext(batch)
+	defer cancel()
+
+	conn, err := p.rp.current().GetContext(ctx)
+	if err == nil {
+		defer conn.Close()
+
+		for _, r := range batch {
+			if err = conn.Send(r.cmd, r.args...); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = conn.Flush()
+		}
+	}
+
+	for _, r := range batch {
+		if err == nil {
+			r.value, r.err = receiveContext(conn, ctx)
+			if isConnError(r.err) {
+				err = r.err
+			}
+		} else {
+			r.err = err
+		}
+		close(r.done)
+	}
+}
+
+// 有调用方的 ctx 永远不会结束时, 批次也不会因 ctx 取消
+func batchContext(batch []*batchReq) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, r := range batch {
+		if r.ctx.Done() == nil {
+			return ctx, cancel
+		}
+	}
+
+	go func() {
+		for _, r := range batch {
+			select {
+			case <-r.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+func (p *autoPipeline) close() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+}
@@ -0,0 +1,95 @@
+package mredis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestAutoPipeline(t *testing.T) {
+	srv := newFakeRedis(t, func(args []string) string {
+		return bulk(args[1])
+	})
+	defer srv.Close()
+
+	var dials int32
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(),
+		WithAutoPipeline(2*time.Millisecond, 64),
+		WithDialer(func(ctx context.Context, network, address string, options ...redis.DialOption) (redis.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return redis.DialContext(ctx, network, address, options...)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	const n = 500
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key:%d", i)
+			if v, err := rp.Get(key).String(); err != nil || v != key {
+				errs <- fmt.Errorf("Get(%s) = %q, %v", key, v, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if d := atomic.LoadInt32(&dials); d > n/10 {
+		t.Errorf("dials = %d, commands were not batched", d)
+	}
+	if s := rp.Stats(); s.Commands != n {
+		t.Errorf("Commands = %d, want %d", s.Commands, n)
+	}
+}
+
+// 服务端不回复时, 调用方放弃等待后批次结束, 连接不会一直被占用
+func TestAutoPipelineStalled(t *testing.T) {
+	release := make(chan struct{})
+	srv := newFakeRedis(t, func(args []string) string {
+		if args[1] == "stall" {
+			<-release
+		}
+		return bulk(args[1])
+	})
+	defer srv.Close()
+	defer close(release)
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(), WithAutoPipeline(time.Millisecond, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := rp.GetCtx(ctx, "stall").Error(); err != context.DeadlineExceeded {
+		t.Fatalf("GetCtx = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for rp.ActiveCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ActiveCount = %d, stalled batch still holds its connection", rp.ActiveCount())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if v, err := rp.Get("next").String(); err != nil || v != "next" {
+		t.Errorf("Get after stall = %q, %v", v, err)
+	}
+}
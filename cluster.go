@@ -0,0 +1,562 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	clusterSlots        = 16384
+	clusterMaxRedirects = 5
+	clusterRetryDelay   = 10 * time.Millisecond // TRYAGAIN 后的等待时间
+	clusterRefreshLimit = 3 * time.Second       // 后台刷新拓扑的超时
+	clusterDrainTimeout = 30 * time.Second      // 移除的节点等待正在执行的命令完成的最长时间
+)
+
+var ErrClusterDown = errors.New("mredis: no cluster node available")
+
+/*
+	ClusterClient 集群模式客户端, 命令方法与 RedisPool 相同
+
+	按 CLUSTER SLOTS 维护 slot -> master 映射, 每个节点一个 RedisPool,
+	自动处理 MOVED/ASK 重定向; MGet、MSet、Del、DelWithReturn 按 slot 拆分后合并结果,
+	MSet 跨 slot 时不再是原子操作
+*/
+type ClusterClient struct {
+	cmdable
+	objects
+	seeds []string
+	opts  []Option // 创建节点连接池使用
+
+	mu     sync.RWMutex
+	slots  []string // slot -> master 地址
+	nodes  map[string]*RedisPool
+	closed bool
+
+	refreshing int32
+}
+
+/*
+	NewClusterClient([]string{"10.0.0.1:7000", "10.0.0.2:7000"}, WithMaxActive(16))
+	addrs 为种子节点, 能连上其中一个即可
+*/
+func NewClusterClient(addrs []string, opts ...Option) (*ClusterClient, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("mredis: cluster addrs is empty")
+	}
+
+	c := &ClusterClient{
+		seeds: append([]string(nil), addrs...),
+		opts:  opts,
+		nodes: make(map[string]*RedisPool),
+	}
+	c.cmdable = c.do
+	c.objects = objects{c: c.cmdable, codec: staticCodec(optionCodec(opts))}
+
+	if err := c.Refresh(context.Background()); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// 重新加载集群拓扑
+func (c *ClusterClient) Refresh(ctx context.Context) error {
+	lastErr := ErrClusterDown
+	for _, addr := range c.knownAddrs() {
+		node, err := c.node(addr)
+		if err != nil {
+			return err
+		}
+
+		slots, err := parseClusterSlots(node.do(ctx, "CLUSTER", "SLOTS"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		c.setSlots(slots)
+		return nil
+	}
+
+	return lastErr
+}
+
+// 当前拓扑中的节点排在种子节点前面
+func (c *ClusterClient) knownAddrs() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	addrs := make([]string, 0, len(c.nodes)+len(c.seeds))
+	seen := make(map[string]bool)
+	for _, addr := range c.slots {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, addr := range c.seeds {
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs
+}
+
+// 替换 slot 映射, 已经不在拓扑中的节点连接池在正在执行的命令完成后关闭
+func (c *ClusterClient) setSlots(slots []string) {
+	inUse := make(map[string]bool)
+	for _, addr := range slots {
+		inUse[addr] = true
+	}
+
+	c.mu.Lock()
+	c.slots = slots
+	var removed []*RedisPool
+	for addr, node := range c.nodes {
+		if !inUse[addr] {
+			removed = append(removed, node)
+			delete(c.nodes, addr)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, node := range removed {
+		go closeWhenDrained(node, clusterDrainTimeout)
+	}
+}
+
+// 等待 rp 上正在执行的命令完成后关闭, 最多等待 timeout; 不像 Shutdown 那样中断阻塞命令
+func closeWhenDrained(rp *RedisPool, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for rp.inflight.active() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	rp.Close()
+}
+
+func (c *ClusterClient) refreshAsync() {
+	if !atomic.CompareAndSwapInt32(&c.refreshing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&c.refreshing, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), clusterRefreshLimit)
+		defer cancel()
+		c.Refresh(ctx)
+	}()
+}
+
+// 获取节点连接池, 不存在时创建
+func (c *ClusterClient) node(addr string) (*RedisPool, error) {
+	c.mu.RLock()
+	node, ok := c.nodes[addr]
+	closed := c.closed
+	c.mu.RUnlock()
+
+	if ok {
+		return node, nil
+	}
+	if closed {
+		return nil, errors.New("mredis: cluster client closed")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if node, ok := c.nodes[addr]; ok {
+		return node, nil
+	}
+
+	node, err := NewRedisPoolWithOptions(addr, c.opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.nodes[addr] = node
+
+	return node, nil
+}
+
+func (c *ClusterClient) slotAddr(slot int) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if slot < len(c.slots) {
+		return c.slots[slot]
+	}
+	return ""
+}
+
+// 没有 key 的命令随机选择一个 master
+func (c *ClusterClient) randomAddr() string {
+	addrs := c.Masters()
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[rand.Intn(len(addrs))]
+}
+
+// 当前所有 master 地址
+func (c *ClusterClient) Masters() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	addrs := make([]string, 0, 8)
+	seen := make(map[string]bool)
+	for _, addr := range c.slots {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs
+}
+
+func (c *ClusterClient) commandAddr(cmd string, args []interface{}) string {
+	if key, ok := commandKey(cmd, args); ok {
+		return c.slotAddr(keySlot(key))
+	}
+	return c.randomAddr()
+}
+
+func (c *ClusterClient) do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	addr := c.commandAddr(cmd, args)
+	asking := false
+
+	var value interface{}
+	err := ErrClusterDown
+	for attempt := 0; attempt <= clusterMaxRedirects; attempt++ {
+		if addr == "" {
+			if err = c.Refresh(ctx); err != nil {
+				return nil, err
+			}
+			if addr = c.commandAddr(cmd, args); addr == "" {
+				return nil, ErrClusterDown
+			}
+		}
+
+		node, e := c.node(addr)
+		if e != nil {
+			return nil, e
+		}
+
+		if asking {
+			value, err = doAsking(ctx, node, cmd, args...)
+			asking = false
+		} else {
+			value, err = node.do(ctx, cmd, args...)
+		}
+
+		rerr, ok := err.(redis.Error)
+		if !ok {
+			if err != nil && ctx.Err() == nil {
+				if _, isNet := err.(net.Error); isNet {
+					c.refreshAsync()
+				}
+			}
+			return value, err
+		}
+
+		kind, slot, target := parseRedirect(rerr)
+		switch kind {
+		case "MOVED":
+			c.setSlot(slot, target)
+			c.refreshAsync()
+			addr = target
+
+		case "ASK":
+			addr = target
+			asking = true
+
+		case "TRYAGAIN":
+			time.Sleep(clusterRetryDelay)
+
+		default:
+			return value, err
+		}
+	}
+
+	return value, err
+}
+
+func (c *ClusterClient) setSlot(slot int, addr string) {
+	c.mu.Lock()
+	if slot >= 0 && slot < len(c.slots) {
+		c.slots[slot] = addr
+	}
+	c.mu.Unlock()
+}
+
+// ASK 重定向: 同一连接上先发送 ASKING
+func doAsking(ctx context.Context, node *RedisPool, cmd string, args ...interface{}) (interface{}, error) {
+	conn, err := node.getConnCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.Send("ASKING"); err != nil {
+		return nil, err
+	}
+	return doContext(conn, ctx, cmd, args...)
+}
+
+// MOVED 3999 127.0.0.1:6381 / ASK 3999 127.0.0.1:6381 / TRYAGAIN ...
+func parseRedirect(err redis.Error) (kind string, slot int, addr string) {
+	fields := strings.Fields(string(err))
+	if len(fields) == 0 {
+		return "", 0, ""
+	}
+
+	switch fields[0] {
+	case "MOVED", "ASK":
+		if len(fields) != 3 {
+			return "", 0, ""
+		}
+		slot, e := strconv.Atoi(fields[1])
+		if e != nil {
+			return "", 0, ""
+		}
+		return fields[0], slot, fields[2]
+
+	case "TRYAGAIN":
+		return fields[0], 0, ""
+	}
+
+	return "", 0, ""
+}
+
+// CLUSTER SLOTS => [[start, end, [ip, port, id], [replica...]], ...]
+func parseClusterSlots(data interface{}, err error) ([]string, error) {
+	ranges, err := redis.Values(data, err)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := make([]string, clusterSlots)
+	for _, r := range ranges {
+		fields, err := redis.Values(r, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("mredis: invalid CLUSTER SLOTS entry %v", fields)
+		}
+
+		start, err := redis.Int(fields[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		end, err := redis.Int(fields[1], nil)
+		if err != nil {
+			return nil, err
+		}
+		master, err := redis.Values(fields[2], nil)
+		if err != nil || len(master) < 2 {
+			return nil, fmt.Errorf("mredis: invalid CLUSTER SLOTS node %v", fields[2])
+		}
+		host, err := redis.String(master[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		port, err := redis.Int(master[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		addr := net.JoinHostPort(host, strconv.Itoa(port))
+		for slot := start; slot <= end && slot < clusterSlots; slot++ {
+			slots[slot] = addr
+		}
+	}
+
+	return slots, nil
+}
+
+// 关闭所有节点连接池
+func (c *ClusterClient) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	nodes := c.nodes
+	c.nodes = make(map[string]*RedisPool)
+	c.mu.Unlock()
+
+	var err error
+	for _, node := range nodes {
+		if e := node.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+/*
+	--------------- 跨 slot 的多 key 命令 -----------------------
+*/
+
+func (c *ClusterClient) MGet(keys ...interface{}) *Reply {
+	return c.MGetCtx(context.Background(), keys...)
+}
+
+func (c *ClusterClient) MGetCtx(ctx context.Context, keys ...interface{}) *Reply {
+	groups := groupBySlot(keys, 1)
+	replies, err := c.scatter(ctx, "MGET", groups)
+	if err != nil {
+		return reply(nil, err)
+	}
+
+	return reply(gatherValues(len(keys), groups, replies))
+}
+
+func (c *ClusterClient) MSet(kvs ...interface{}) error {
+	return c.MSetCtx(context.Background(), kvs...)
+}
+
+func (c *ClusterClient) MSetCtx(ctx context.Context, kvs ...interface{}) error {
+	if len(kvs)%2 != 0 {
+		return errors.New("invalid arguments number")
+	}
+
+	_, err := c.scatter(ctx, "MSET", groupBySlot(kvs, 2))
+	return err
+}
+
+func (c *ClusterClient) Del(keys ...interface{}) error {
+	return c.DelCtx(context.Background(), keys...)
+}
+
+func (c *ClusterClient) DelCtx(ctx context.Context, keys ...interface{}) error {
+	_, err := c.DelWithReturnCtx(ctx, keys...)
+	return err
+}
+
+func (c *ClusterClient) DelWithReturn(keys ...interface{}) (int, error) {
+	return c.DelWithReturnCtx(context.Background(), keys...)
+}
+
+func (c *ClusterClient) DelWithReturnCtx(ctx context.Context, keys ...interface{}) (int, error) {
+	replies, err := c.scatter(ctx, "DEL", groupBySlot(keys, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	return sumInts(replies)
+}
+
+// 在所有 master 上执行 KEYS 并合并
+func (c *ClusterClient) Keys(pattern interface{}) *Reply {
+	return c.KeysCtx(context.Background(), pattern)
+}
+
+func (c *ClusterClient) KeysCtx(ctx context.Context, pattern interface{}) *Reply {
+	keys := make([]interface{}, 0, 64)
+	for _, addr := range c.Masters() {
+		node, err := c.node(addr)
+		if err != nil {
+			return reply(nil, err)
+		}
+
+		values, err := redis.Values(node.do(ctx, "KEYS", pattern))
+		if err != nil {
+			return reply(nil, err)
+		}
+		keys = append(keys, values...)
+	}
+
+	return reply(keys, nil)
+}
+
+func groupBySlot(args []interface{}, step int) []*argGroup {
+	return groupArgs(args, step, func(key string) interface{} {
+		return keySlot(key)
+	})
+}
+
+// 每组并发执行一次 cmd, 返回与 groups 对应的结果
+func (c *ClusterClient) scatter(ctx context.Context, cmd string, groups []*argGroup) ([]interface{}, error) {
+	return scatter(groups, func(g *argGroup) (interface{}, error) {
+		return c.do(ctx, cmd, g.args...)
+	})
+}
+
+/*
+	--------------- slot -----------------------
+*/
+
+// 不带 key 的命令
+var keylessCommands = map[string]bool{
+	"PUBLISH":  true,
+	"KEYS":     true,
+	"PING":     true,
+	"ECHO":     true,
+	"INFO":     true,
+	"CLUSTER":  true,
+	"SCRIPT":   true,
+	"FUNCTION": true,
+}
+
+// 命令的第一个 key
+func commandKey(cmd string, args []interface{}) (string, bool) {
+	if isScriptCommand(cmd) {
+		return scriptKey(args)
+	}
+	if len(args) == 0 || keylessCommands[strings.ToUpper(cmd)] {
+		return "", false
+	}
+	return keyString(args[0]), true
+}
+
+func keyString(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case []byte:
+		return string(k)
+	}
+	return fmt.Sprint(key)
+}
+
+// key 中有非空的 {tag} 时只用 tag 计算 slot
+func hashTag(key string) string {
+	if s := strings.IndexByte(key, '{'); s >= 0 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			return key[s+1 : s+1+e]
+		}
+	}
+	return key
+}
+
+func keySlot(key string) int {
+	return int(crc16(hashTag(key)) % clusterSlots)
+}
+
+// CRC16-CCITT (XMODEM), redis cluster 规范使用的算法
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
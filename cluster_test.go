@@ -0,0 +1,203 @@
+package mredis
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNode 只处理 [from, to] 范围内 slot 的 key, 其他 key 返回 MOVED
+type fakeNode struct {
+	*fakeRedis
+	mu       sync.Mutex
+	data     map[string]string
+	from, to int
+	other    func() string
+	topology func() string
+}
+
+func newFakeNode(t *testing.T, from, to int) *fakeNode {
+	n := &fakeNode{data: make(map[string]string), from: from, to: to}
+	n.fakeRedis = newFakeRedis(t, n.handle)
+	return n
+}
+
+func (n *fakeNode) handle(args []string) string {
+	cmd := strings.ToUpper(args[0])
+	if cmd == "CLUSTER" {
+		return n.topology()
+	}
+
+	for _, key := range args[1:] {
+		if slot := keySlot(key); slot < n.from || slot > n.to {
+			return "-MOVED " + strconv.Itoa(slot) + " " + n.other() + "\r\n"
+		}
+		if cmd == "SET" || cmd == "MSET" {
+			break
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch cmd {
+	case "GET":
+		v, ok := n.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+
+	case "SET":
+		n.data[args[1]] = args[2]
+		return "+OK\r\n"
+
+	case "MSET":
+		for i := 1; i+1 < len(args); i += 2 {
+			n.data[args[i]] = args[i+1]
+		}
+		return "+OK\r\n"
+
+	case "MGET":
+		items := make([]string, 0, len(args)-1)
+		for _, key := range args[1:] {
+			if v, ok := n.data[key]; ok {
+				items = append(items, bulk(v))
+			} else {
+				items = append(items, "$-1\r\n")
+			}
+		}
+		return array(items...)
+
+	case "DEL":
+		count := 0
+		for _, key := range args[1:] {
+			if _, ok := n.data[key]; ok {
+				delete(n.data, key)
+				count++
+			}
+		}
+		return ":" + strconv.Itoa(count) + "\r\n"
+	}
+
+	return "-ERR unknown command\r\n"
+}
+
+func slotsEntry(from, to int, addr string) string {
+	host, port, _ := net.SplitHostPort(addr)
+	return array(":"+strconv.Itoa(from)+"\r\n", ":"+strconv.Itoa(to)+"\r\n", array(bulk(host), ":"+port+"\r\n"))
+}
+
+func TestClusterClient(t *testing.T) {
+	a := newFakeNode(t, 0, 8191)
+	defer a.Close()
+	b := newFakeNode(t, 8192, clusterSlots-1)
+	defer b.Close()
+
+	a.other = func() string { return b.Addr().String() }
+	b.other = func() string { return a.Addr().String() }
+
+	// 初始拓扑故意把所有 slot 都指向 a, 依靠 MOVED 纠正
+	stale := array(slotsEntry(0, clusterSlots-1, a.Addr().String()))
+	a.topology = func() string { return stale }
+	b.topology = a.topology
+
+	c, err := NewClusterClient([]string{a.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	keys := []interface{}{"k1", "k2", "k3", "k4", "{user}a", "{user}b"}
+	kvs := make([]interface{}, 0, len(keys)*2)
+	for _, k := range keys {
+		kvs = append(kvs, k, "v-"+k.(string))
+	}
+
+	if err := c.MSet(kvs...); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := c.MGet(keys...).Strings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, k := range keys {
+		if values[i] != "v-"+k.(string) {
+			t.Errorf("MGet[%d] = %q, want %q", i, values[i], "v-"+k.(string))
+		}
+	}
+
+	if v, err := c.Get("k3").String(); err != nil || v != "v-k3" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+
+	n, err := c.DelWithReturn(append(keys, "missing")...)
+	if err != nil || n != len(keys) {
+		t.Errorf("DelWithReturn = %d, %v, want %d", n, err, len(keys))
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	// 取自 redis cluster 规范
+	if s := keySlot("123456789"); s != int(0x31C3%clusterSlots) {
+		t.Errorf("keySlot(123456789) = %d", s)
+	}
+	if keySlot("{user1000}.following") != keySlot("{user1000}.followers") {
+		t.Error("hash tag keys should share a slot")
+	}
+	if hashTag("foo{}{bar}") != "foo{}{bar}" {
+		t.Error("empty hash tag should hash the whole key")
+	}
+}
+
+// 拓扑中移除的节点等正在执行的命令完成后才关闭
+func TestCloseWhenDrained(t *testing.T) {
+	rp, err := NewRedisPoolWithOptions("127.0.0.1:6379")
+	if err != nil {
+		t.Fatal(err)
+	}
+	isClosed := func() bool {
+		rp.mu.RLock()
+		defer rp.mu.RUnlock()
+		return rp.closed
+	}
+
+	if err := rp.inflight.acquire(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		closeWhenDrained(rp, time.Second)
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	if isClosed() {
+		t.Fatal("pool closed while a command was in flight")
+	}
+
+	rp.inflight.release()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("pool not closed after commands drained")
+	}
+	if !isClosed() {
+		t.Error("pool not closed")
+	}
+
+	// 超时后不再等待
+	stuck, err := NewRedisPoolWithOptions("127.0.0.1:6379")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stuck.inflight.acquire()
+	closeWhenDrained(stuck, 20*time.Millisecond)
+	if _, err := stuck.current().Get().Do("PING"); err == nil {
+		t.Error("pool still usable after drain timeout")
+	}
+}
@@ -0,0 +1,142 @@
+package mredis
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+/*
+	Codec 把 Go 对象编码成 redis 中保存的值, 用于 SetObject/GetObject 等对象命令和 Reply.Decode
+
+	使用顺序: UseCodec 指定的 ctx > 客户端的 codec > JSONCodec; 客户端的 codec 由 WithCodec(URL 参数 codec=gob)、
+	WithReadWriteCodec 或 WithShardedCodec 设置
+*/
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	JSONCodec     Codec = jsonCodec{}
+	GobCodec      Codec = gobCodec{}
+	MsgpackCodec  Codec = msgpackCodec{}
+	ProtobufCodec Codec = protobufCodec{} // 对象必须实现 proto.Message
+)
+
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: map[string]Codec{
+	"json":     JSONCodec,
+	"gob":      GobCodec,
+	"msgpack":  MsgpackCodec,
+	"protobuf": ProtobufCodec,
+}}
+
+// 注册自定义 codec, 之后可以在 URL 中通过 codec=name 使用
+func RegisterCodec(name string, c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+
+	codecs.m[name] = c
+}
+
+func codecByName(name string) (Codec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	c, ok := codecs.m[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec: %s", name)
+	}
+	return c, nil
+}
+
+// 连接池默认使用的 codec
+func WithCodec(c Codec) Option {
+	return func(opt *poolOption) { opt.Codec = c }
+}
+
+// opts 中 WithCodec 设置的 codec
+func optionCodec(opts []Option) Codec {
+	opt := &poolOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	return opt.Codec
+}
+
+type codecKey struct{}
+
+// 返回的 ctx 用于对象命令时使用 c 编解码, 覆盖连接池的设置
+func UseCodec(ctx context.Context, c Codec) context.Context {
+	return context.WithValue(ctx, codecKey{}, c)
+}
+
+func codecFrom(ctx context.Context, def Codec) Codec {
+	if c, ok := ctx.Value(codecKey{}).(Codec); ok {
+		return c
+	}
+	if def != nil {
+		return def
+	}
+	return JSONCodec
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("mredis: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("mredis: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
@@ -0,0 +1,258 @@
+package mredis
+
+import "context"
+
+/*
+	Commander 所有命令方法, RedisPool、ClusterClient、ReadWriteClient、ShardedPool、Session 都实现了该接口,
+	业务代码依赖接口, 测试时可以替换成 mock; 只用到部分命令时可以只依赖 Strings、Hashes 等子接口
+
+	Tx 和 Pipe 不实现 Commander 及其子接口: 它们的命令方法返回 *Future, 结果在 EXEC/Exec 之后才能读取
+*/
+type Commander interface {
+	Do(cmd string, args ...interface{}) *Reply
+	DoCtx(ctx context.Context, cmd string, args ...interface{}) *Reply
+
+	Strings
+	Hashes
+	Lists
+	Sets
+	ZSets
+	Geo
+	Keys
+	Publisher
+	Scripting
+	Objects
+}
+
+// Strings 字符串命令
+type Strings interface {
+	Get(key interface{}) *Reply
+	GetCtx(ctx context.Context, key interface{}) *Reply
+	Set(key interface{}, value interface{}) (e error)
+	SetCtx(ctx context.Context, key interface{}, value interface{}) (e error)
+	SetEx(key interface{}, seconds int, value interface{}) (e error)
+	SetExCtx(ctx context.Context, key interface{}, seconds int, value interface{}) (e error)
+	SetNx(key interface{}, value interface{}) bool
+	SetNxCtx(ctx context.Context, key interface{}, value interface{}) bool
+	Incr(key interface{}) *Reply
+	IncrCtx(ctx context.Context, key interface{}) *Reply
+	IncrBy(key interface{}, value interface{}) *Reply
+	IncrByCtx(ctx context.Context, key interface{}, value interface{}) *Reply
+	MGet(keys ...interface{}) *Reply
+	MGetCtx(ctx context.Context, keys ...interface{}) *Reply
+	MSet(kvs ...interface{}) (e error)
+	MSetCtx(ctx context.Context, kvs ...interface{}) (e error)
+}
+
+// Hashes 哈希命令
+type Hashes interface {
+	HSetWithReturn(key interface{}, member, value interface{}) *Reply
+	HSetWithReturnCtx(ctx context.Context, key interface{}, member, value interface{}) *Reply
+	HSet(key interface{}, member, value interface{}) (e error)
+	HSetCtx(ctx context.Context, key interface{}, member, value interface{}) (e error)
+	HGet(key interface{}, name interface{}) *Reply
+	HGetCtx(ctx context.Context, key interface{}, name interface{}) *Reply
+	HLen(key interface{}) (num int64, e error)
+	HLenCtx(ctx context.Context, key interface{}) (num int64, e error)
+	HMGet(args ...interface{}) *Reply
+	HMGetCtx(ctx context.Context, args ...interface{}) *Reply
+	HMSet(args ...interface{}) (e error)
+	HMSetCtx(ctx context.Context, args ...interface{}) (e error)
+	HGetAll(key interface{}) *Reply
+	HGetAllCtx(ctx context.Context, key interface{}) *Reply
+	HSetStruct(key interface{}, v interface{}) error
+	HSetStructCtx(ctx context.Context, key interface{}, v interface{}) error
+	HDel(args ...interface{}) error
+	HDelCtx(ctx context.Context, args ...interface{}) error
+	HDelWithReturn(args ...interface{}) *Reply
+	HDelWithReturnCtx(ctx context.Context, args ...interface{}) *Reply
+	HIncBy(key interface{}, field interface{}, increment int64) (e error)
+	HIncByCtx(ctx context.Context, key interface{}, field interface{}, increment int64) (e error)
+	HIncByWithReturn(key interface{}, field interface{}, increment int64) *Reply
+	HIncByWithReturnCtx(ctx context.Context, key interface{}, field interface{}, increment int64) *Reply
+}
+
+// Lists 列表命令
+type Lists interface {
+	RPush(args ...interface{}) (e error)
+	RPushCtx(ctx context.Context, args ...interface{}) (e error)
+	RPushWithReturn(args ...interface{}) (llen int64, e error)
+	RPushWithReturnCtx(ctx context.Context, args ...interface{}) (llen int64, e error)
+	LPop(key interface{}) *Reply
+	LPopCtx(ctx context.Context, key interface{}) *Reply
+	LPush(args ...interface{}) (e error)
+	LPushCtx(ctx context.Context, args ...interface{}) (e error)
+	LPushWithReturn(args ...interface{}) (llen int64, e error)
+	LPushWithReturnCtx(ctx context.Context, args ...interface{}) (llen int64, e error)
+	RPop(key interface{}) *Reply
+	RPopCtx(ctx context.Context, key interface{}) *Reply
+	BRPop(args ...interface{}) *Reply
+	BRPopCtx(ctx context.Context, args ...interface{}) *Reply
+	BLPop(args ...interface{}) *Reply
+	BLPopCtx(ctx context.Context, args ...interface{}) *Reply
+	LRange(key interface{}, start, stop interface{}) *Reply
+	LRangeCtx(ctx context.Context, key interface{}, start, stop interface{}) *Reply
+	LLen(key interface{}) (length int64, e error)
+	LLenCtx(ctx context.Context, key interface{}) (length int64, e error)
+	LTrim(key interface{}, start, end int64) (e error)
+	LTrimCtx(ctx context.Context, key interface{}, start, end int64) (e error)
+	LRem(key interface{}, count int64, value interface{}) (e error)
+	LRemCtx(ctx context.Context, key interface{}, count int64, value interface{}) (e error)
+	LRemWithReturn(key interface{}, count int64, value interface{}) (int, error)
+	LRemWithReturnCtx(ctx context.Context, key interface{}, count int64, value interface{}) (int, error)
+	LIndex(key interface{}, index int64) *Reply
+	LIndexCtx(ctx context.Context, key interface{}, index int64) *Reply
+	LSet(key interface{}, idx int64, data interface{}) (e error)
+	LSetCtx(ctx context.Context, key interface{}, idx int64, data interface{}) (e error)
+	LFront(key interface{}) *Reply
+	LFrontCtx(ctx context.Context, key interface{}) *Reply
+	LBack(key interface{}) *Reply
+	LBackCtx(ctx context.Context, key interface{}) *Reply
+}
+
+// Sets 集合命令
+type Sets interface {
+	SAdd(args ...interface{}) (e error)
+	SAddCtx(ctx context.Context, args ...interface{}) (e error)
+	SAddWithReturn(args ...interface{}) (int64, error)
+	SAddWithReturnCtx(ctx context.Context, args ...interface{}) (int64, error)
+	SRem(args ...interface{}) (e error)
+	SRemCtx(ctx context.Context, args ...interface{}) (e error)
+	SRemWithReturn(args ...interface{}) (int64, error)
+	SRemWithReturnCtx(ctx context.Context, args ...interface{}) (int64, error)
+	SIsMember(key interface{}, value interface{}) (isMember bool, e error)
+	SIsMemberCtx(ctx context.Context, key interface{}, value interface{}) (isMember bool, e error)
+	SMembers(key interface{}) *Reply
+	SMembersCtx(ctx context.Context, key interface{}) *Reply
+	SCard(key interface{}) (count int64, e error)
+	SCardCtx(ctx context.Context, key interface{}) (count int64, e error)
+	SRandMembers(key interface{}, count int) *Reply
+	SRandMembersCtx(ctx context.Context, key interface{}, count int) *Reply
+}
+
+// ZSets 有序集合命令
+type ZSets interface {
+	ZAdd(args ...interface{}) (e error)
+	ZAddCtx(ctx context.Context, args ...interface{}) (e error)
+	ZAddWithReturn(args ...interface{}) (int64, error)
+	ZAddWithReturnCtx(ctx context.Context, args ...interface{}) (int64, error)
+	ZCount(key interface{}, min, max float64) (count int64, e error)
+	ZCountCtx(ctx context.Context, key interface{}, min, max float64) (count int64, e error)
+	ZCard(key interface{}) (num int64, e error)
+	ZCardCtx(ctx context.Context, key interface{}) (num int64, e error)
+	ZRank(key interface{}, member interface{}, asc bool) (rank int64, e error)
+	ZRankCtx(ctx context.Context, key interface{}, member interface{}, asc bool) (rank int64, e error)
+	ZIncBy(key interface{}, increment interface{}, member interface{}) (e error)
+	ZIncByCtx(ctx context.Context, key interface{}, increment interface{}, member interface{}) (e error)
+	ZIncByWithReturn(key interface{}, increment interface{}, member interface{}) (score int64, e error)
+	ZIncByWithReturnCtx(ctx context.Context, key interface{}, increment interface{}, member interface{}) (score int64, e error)
+	ZScore(key interface{}, item interface{}) (score int64, e error)
+	ZScoreCtx(ctx context.Context, key interface{}, item interface{}) (score int64, e error)
+	ZIsMember(key interface{}, item interface{}) (isMember bool, e error)
+	ZIsMemberCtx(ctx context.Context, key interface{}, item interface{}) (isMember bool, e error)
+	ZRem(args ...interface{}) (e error)
+	ZRemCtx(ctx context.Context, args ...interface{}) (e error)
+	ZRemWithReturn(args ...interface{}) (n int, e error)
+	ZRemWithReturnCtx(ctx context.Context, args ...interface{}) (n int, e error)
+	ZRange(key interface{}, start, end int) *Reply
+	ZRangeCtx(ctx context.Context, key interface{}, start, end int) *Reply
+	ZRevRange(key interface{}, start, end int) *Reply
+	ZRevRangeCtx(ctx context.Context, key interface{}, start, end int) *Reply
+	ZRangePS(key interface{}, cur int, ps int) *Reply
+	ZRangePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply
+	ZRevRangePS(key interface{}, cur int, ps int) *Reply
+	ZRevRangePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply
+	ZRangeWithScore(key interface{}, start, end int) *Reply
+	ZRangeWithScoreCtx(ctx context.Context, key interface{}, start, end int) *Reply
+	ZRevRangeWithScore(key interface{}, start, end int) *Reply
+	ZRevRangeWithScoreCtx(ctx context.Context, key interface{}, start, end int) *Reply
+	ZRangeWithScorePS(key interface{}, cur int, ps int) *Reply
+	ZRangeWithScorePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply
+	ZRevRangeWithScorePS(key interface{}, cur int, ps int) *Reply
+	ZRevRangeWithScorePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply
+	ZRangeByScore(key interface{}, min, max interface{}, limit int) *Reply
+	ZRangeByScoreCtx(ctx context.Context, key interface{}, min, max interface{}, limit int) *Reply
+	ZRevRangeByScore(key interface{}, min, max interface{}, limit int) *Reply
+	ZRevRangeByScoreCtx(ctx context.Context, key interface{}, min, max interface{}, limit int) *Reply
+	ZRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Reply
+	ZRangeByScoreWithScoreCtx(ctx context.Context, key interface{}, min, max int64, limit int) *Reply
+	ZRevRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Reply
+	ZRevRangeByScoreWithScoreCtx(ctx context.Context, key interface{}, min, max int64, limit int) *Reply
+	ZRangeByScoreWithScorePS(key interface{}, min, max int64, cur, ps int) *Reply
+	ZRangeByScoreWithScorePSCtx(ctx context.Context, key interface{}, min, max int64, cur, ps int) *Reply
+	ZRevRangeByScoreWithScorePS(key interface{}, min, max int64, cur, ps int) *Reply
+	ZRevRangeByScoreWithScorePSCtx(ctx context.Context, key interface{}, min, max int64, cur, ps int) *Reply
+	ZRemRangeByScore(key interface{}, min, max int64) error
+	ZRemRangeByScoreCtx(ctx context.Context, key interface{}, min, max int64) error
+}
+
+// Geo 地理位置命令
+type Geo interface {
+	GeoAdd(key string, values ...interface{}) (int64, error)
+	GeoAddCtx(ctx context.Context, key string, values ...interface{}) (int64, error)
+	GeoDist(key interface{}, mem1, mem2 uint32, unit string) (distance float64, e error)
+	GeoDistCtx(ctx context.Context, key interface{}, mem1, mem2 uint32, unit string) (distance float64, e error)
+	GeoPos(mem1 ...uint32) error
+}
+
+// Keys key 相关命令
+type Keys interface {
+	Exists(key interface{}) (bool, error)
+	ExistsCtx(ctx context.Context, key interface{}) (bool, error)
+	DelWithReturn(keys ...interface{}) (int, error)
+	DelWithReturnCtx(ctx context.Context, keys ...interface{}) (int, error)
+	Del(key ...interface{}) (e error)
+	DelCtx(ctx context.Context, key ...interface{}) (e error)
+	ExpireWithReturn(expire int64, key interface{}) (int, error)
+	ExpireWithReturnCtx(ctx context.Context, expire int64, key interface{}) (int, error)
+	Expire(expire int64, key interface{}) error
+	ExpireCtx(ctx context.Context, expire int64, key interface{}) error
+	ExpireAtWithReturn(expireAt int64, key interface{}) (ret int, e error)
+	ExpireAtWithReturnCtx(ctx context.Context, expireAt int64, key interface{}) (ret int, e error)
+	ExpireAt(expireAt int64, key interface{}) error
+	ExpireAtCtx(ctx context.Context, expireAt int64, key interface{}) error
+	TTL(key interface{}) (expire int, e error)
+	TTLCtx(ctx context.Context, key interface{}) (expire int, e error)
+	Keys(pattern interface{}) *Reply
+	KeysCtx(ctx context.Context, pattern interface{}) *Reply
+}
+
+// Publisher 发布消息
+type Publisher interface {
+	Publish(channel, value interface{}) error
+	PublishCtx(ctx context.Context, channel, value interface{}) error
+}
+
+// Scripting lua 脚本和 redis 7 Functions
+type Scripting interface {
+	RunScript(s *Script, keysAndArgs ...interface{}) *Reply
+	RunScriptCtx(ctx context.Context, s *Script, keysAndArgs ...interface{}) *Reply
+	FCall(function string, keys []interface{}, args ...interface{}) *Reply
+	FCallCtx(ctx context.Context, function string, keys []interface{}, args ...interface{}) *Reply
+	FCallRO(function string, keys []interface{}, args ...interface{}) *Reply
+	FCallROCtx(ctx context.Context, function string, keys []interface{}, args ...interface{}) *Reply
+}
+
+// Objects 通过 Codec 读写 Go 对象
+type Objects interface {
+	SetObject(key interface{}, v interface{}) error
+	SetObjectCtx(ctx context.Context, key interface{}, v interface{}) error
+	GetObject(key interface{}) *Reply
+	GetObjectCtx(ctx context.Context, key interface{}) *Reply
+	HSetObject(key interface{}, field interface{}, v interface{}) error
+	HSetObjectCtx(ctx context.Context, key interface{}, field interface{}, v interface{}) error
+	HGetObject(key interface{}, field interface{}) *Reply
+	HGetObjectCtx(ctx context.Context, key interface{}, field interface{}) *Reply
+	LPushObject(key interface{}, values ...interface{}) error
+	LPushObjectCtx(ctx context.Context, key interface{}, values ...interface{}) error
+	LRangeObjects(key interface{}, start, stop int) *Reply
+	LRangeObjectsCtx(ctx context.Context, key interface{}, start, stop int) *Reply
+}
+
+var (
+	_ Commander = (*RedisPool)(nil)
+	_ Commander = (*ClusterClient)(nil)
+	_ Commander = (*ReadWriteClient)(nil)
+	_ Commander = (*ShardedPool)(nil)
+	_ Commander = (*Session)(nil)
+)
@@ -0,0 +1,17 @@
+package mredis
+
+import "context"
+
+/*
+	Do 执行任意命令, 用于 mredis 没有封装的命令(OBJECT, WAIT, XADD...),
+	与内置命令一样经过连接池、超时、统计、集群路由和读写分离, 结果通过 Reply 转换:
+
+	id, err := rp.Do("XADD", "stream", "*", "field", "value").String()
+*/
+func (c cmdable) Do(cmd string, args ...interface{}) *Reply {
+	return c.DoCtx(context.Background(), cmd, args...)
+}
+
+func (c cmdable) DoCtx(ctx context.Context, cmd string, args ...interface{}) *Reply {
+	return reply(c(ctx, cmd, args...))
+}
@@ -0,0 +1,204 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"regexp"
+)
+
+var (
+	ErrLibraryNotLoaded = errors.New("mredis: function library not loaded")
+	ErrLibraryMismatch  = errors.New("mredis: deployed function library does not match")
+)
+
+/*
+	redis 7 Functions
+
+	库的源码第一行为 #!lua name=<库名>, 可以用 -- version: <版本> 注释标记版本:
+
+	//go:embed mylib.lua
+	var mylib string
+
+	err := rp.CheckFunctionLibrary(mylib)
+	if errors.Is(err, ErrLibraryNotLoaded) || errors.Is(err, ErrLibraryMismatch) {
+		_, err = rp.FunctionLoad(mylib, true)
+	}
+	n, err := rp.FCall("my_incr", []interface{}{"counter"}, 1).Int64()
+*/
+
+var (
+	libraryNameRegexp    = regexp.MustCompile(`\A#!\w+\s+name=(\S+)`)
+	libraryVersionRegexp = regexp.MustCompile(`(?m)^\s*--\s*version\s*[:=]\s*(\S+)`)
+)
+
+// FUNCTION LIST 中的一个库
+type FunctionLibrary struct {
+	Name      string
+	Engine    string
+	Functions []string
+	Code      string
+	Version   string // 源码中 -- version: 注释的值, 没有时为空
+}
+
+func libraryName(code string) string {
+	if m := libraryNameRegexp.FindStringSubmatch(code); len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
+
+func libraryVersion(code string) string {
+	if m := libraryVersionRegexp.FindStringSubmatch(code); len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
+
+func (c cmdable) FCall(function string, keys []interface{}, args ...interface{}) *Reply {
+	return c.FCallCtx(context.Background(), function, keys, args...)
+}
+
+func (c cmdable) FCallCtx(ctx context.Context, function string, keys []interface{}, args ...interface{}) *Reply {
+	return reply(c(ctx, "FCALL", fcallArgs(function, keys, args)...))
+}
+
+// 只读函数, ReadWriteClient 会发往从库
+func (c cmdable) FCallRO(function string, keys []interface{}, args ...interface{}) *Reply {
+	return c.FCallROCtx(context.Background(), function, keys, args...)
+}
+
+func (c cmdable) FCallROCtx(ctx context.Context, function string, keys []interface{}, args ...interface{}) *Reply {
+	return reply(c(ctx, "FCALL_RO", fcallArgs(function, keys, args)...))
+}
+
+func fcallArgs(function string, keys, args []interface{}) []interface{} {
+	a := make([]interface{}, 0, 2+len(keys)+len(args))
+	a = append(a, function, len(keys))
+	a = append(a, keys...)
+	return append(a, args...)
+}
+
+// 加载库, replace 为 true 时替换同名库, 返回库名
+func (rp *RedisPool) FunctionLoad(code string, replace bool) (string, error) {
+	return rp.FunctionLoadCtx(context.Background(), code, replace)
+}
+
+func (rp *RedisPool) FunctionLoadCtx(ctx context.Context, code string, replace bool) (string, error) {
+	if replace {
+		return redis.String(rp.do(ctx, "FUNCTION", "LOAD", "REPLACE", code))
+	}
+	return redis.String(rp.do(ctx, "FUNCTION", "LOAD", code))
+}
+
+func (rp *RedisPool) FunctionDelete(library string) error {
+	return rp.FunctionDeleteCtx(context.Background(), library)
+}
+
+func (rp *RedisPool) FunctionDeleteCtx(ctx context.Context, library string) error {
+	_, err := rp.do(ctx, "FUNCTION", "DELETE", library)
+	return err
+}
+
+// pattern 为空时返回所有库, 包括源码
+func (rp *RedisPool) FunctionList(pattern string) ([]FunctionLibrary, error) {
+	return rp.FunctionListCtx(context.Background(), pattern)
+}
+
+func (rp *RedisPool) FunctionListCtx(ctx context.Context, pattern string) ([]FunctionLibrary, error) {
+	args := []interface{}{"LIST"}
+	if pattern != "" {
+		args = append(args, "LIBRARYNAME", pattern)
+	}
+	args = append(args, "WITHCODE")
+
+	values, err := redis.Values(rp.do(ctx, "FUNCTION", args...))
+	if err != nil {
+		return nil, err
+	}
+
+	libs := make([]FunctionLibrary, 0, len(values))
+	for _, v := range values {
+		lib, err := parseFunctionLibrary(v)
+		if err != nil {
+			return nil, err
+		}
+		libs = append(libs, lib)
+	}
+	return libs, nil
+}
+
+// library_name, engine, functions, library_code 组成的 key/value 列表
+func parseFunctionLibrary(v interface{}) (FunctionLibrary, error) {
+	var lib FunctionLibrary
+	fields, err := redis.Values(v, nil)
+	if err != nil || len(fields)%2 != 0 {
+		return lib, fmt.Errorf("mredis: unexpected FUNCTION LIST reply %v", v)
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		name, _ := redis.String(fields[i], nil)
+		switch name {
+		case "library_name":
+			lib.Name, _ = redis.String(fields[i+1], nil)
+		case "engine":
+			lib.Engine, _ = redis.String(fields[i+1], nil)
+		case "library_code":
+			lib.Code, _ = redis.String(fields[i+1], nil)
+			lib.Version = libraryVersion(lib.Code)
+		case "functions":
+			functions, _ := redis.Values(fields[i+1], nil)
+			for _, f := range functions {
+				attrs, _ := redis.Values(f, nil)
+				for j := 0; j+1 < len(attrs); j += 2 {
+					if k, _ := redis.String(attrs[j], nil); k == "name" {
+						fn, _ := redis.String(attrs[j+1], nil)
+						lib.Functions = append(lib.Functions, fn)
+					}
+				}
+			}
+		}
+	}
+	return lib, nil
+}
+
+/*
+	CheckFunctionLibrary 启动时检查服务端的库是否与 code 一致:
+	code 中有 -- version: 时比较版本, 否则比较源码;
+	未加载返回 ErrLibraryNotLoaded, 不一致返回 ErrLibraryMismatch
+*/
+func (rp *RedisPool) CheckFunctionLibrary(code string) error {
+	return rp.CheckFunctionLibraryCtx(context.Background(), code)
+}
+
+func (rp *RedisPool) CheckFunctionLibraryCtx(ctx context.Context, code string) error {
+	name := libraryName(code)
+	if name == "" {
+		return errors.New("mredis: missing library name, code must start with #!lua name=<library>")
+	}
+
+	libs, err := rp.FunctionListCtx(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	for _, lib := range libs {
+		if lib.Name != name {
+			continue
+		}
+
+		if version := libraryVersion(code); version != "" {
+			if lib.Version != version {
+				return fmt.Errorf("%w: %s version %q, want %q", ErrLibraryMismatch, name, lib.Version, version)
+			}
+			return nil
+		}
+		if lib.Code != code {
+			return fmt.Errorf("%w: %s code differs", ErrLibraryMismatch, name)
+		}
+		return nil
+	}
+
+	return ErrLibraryNotLoaded
+}
@@ -0,0 +1,87 @@
+package mredis
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+const testLibrary = `#!lua name=mylib
+-- version: 2
+redis.register_function('my_incr', function(keys, args) return redis.call('INCRBY', keys[1], args[1]) end)`
+
+func TestFunctions(t *testing.T) {
+	var mu sync.Mutex
+	deployed := ""
+	var fcall []string
+	srv := newFakeRedis(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch args[0] {
+		case "FUNCTION":
+			switch args[1] {
+			case "LOAD":
+				deployed = args[len(args)-1]
+				return bulk(libraryName(deployed))
+			case "LIST":
+				if deployed == "" {
+					return "*0\r\n"
+				}
+				fn := array(bulk("name"), bulk("my_incr"), bulk("description"), "$-1\r\n", bulk("flags"), "*0\r\n")
+				return array(array(
+					bulk("library_name"), bulk(libraryName(deployed)),
+					bulk("engine"), bulk("LUA"),
+					bulk("functions"), array(fn),
+					bulk("library_code"), bulk(deployed),
+				))
+			}
+		case "FCALL":
+			fcall = args
+			return ":7\r\n"
+		}
+		return "+OK\r\n"
+	})
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if err := rp.CheckFunctionLibrary(testLibrary); err != ErrLibraryNotLoaded {
+		t.Errorf("Check before load = %v, want ErrLibraryNotLoaded", err)
+	}
+
+	mu.Lock()
+	deployed = "#!lua name=mylib\n-- version: 1\n"
+	mu.Unlock()
+	if err := rp.CheckFunctionLibrary(testLibrary); !errors.Is(err, ErrLibraryMismatch) {
+		t.Errorf("Check old version = %v, want ErrLibraryMismatch", err)
+	}
+
+	if name, err := rp.FunctionLoad(testLibrary, true); err != nil || name != "mylib" {
+		t.Fatalf("FunctionLoad = %q, %v", name, err)
+	}
+	if err := rp.CheckFunctionLibrary(testLibrary); err != nil {
+		t.Errorf("Check after load = %v", err)
+	}
+
+	libs, err := rp.FunctionList("")
+	if err != nil || len(libs) != 1 {
+		t.Fatalf("FunctionList = %v, %v", libs, err)
+	}
+	if lib := libs[0]; lib.Name != "mylib" || lib.Version != "2" || len(lib.Functions) != 1 || lib.Functions[0] != "my_incr" {
+		t.Errorf("library = %+v", lib)
+	}
+
+	if n, err := rp.FCall("my_incr", []interface{}{"counter"}, 7).Int(); err != nil || n != 7 {
+		t.Errorf("FCall = %d, %v", n, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(fcall) != 5 || fcall[1] != "my_incr" || fcall[2] != "1" || fcall[3] != "counter" {
+		t.Errorf("FCALL args = %v", fcall)
+	}
+}
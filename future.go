@@ -0,0 +1,335 @@
+package mredis
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNotExecuted = errors.New("mredis: command not executed yet")
+
+/*
+	Future 排队中的一条命令, Pipeline/Tx 执行后通过 Reply 取结果,
+	每条命令的错误单独保存在各自的 Reply 中
+*/
+type Future struct {
+	cmd  string
+	args []interface{}
+	r    *Reply
+}
+
+func (f *Future) Reply() *Reply {
+	if f.r == nil {
+		return reply(nil, ErrNotExecuted)
+	}
+	return f.r
+}
+
+func (f *Future) resolve(value interface{}, err error) {
+	f.r = reply(value, err)
+}
+
+// 没有得到结果的命令都以 err 结束
+func resolveAll(futures []*Future, err error) {
+	for _, f := range futures {
+		f.resolve(nil, err)
+	}
+}
+
+/*
+	futureCmdable 排队一条命令并返回 Future, Pipe 和 Tx 上的命令方法定义在 futureCmdable 上
+
+	与 RedisPool 的同名命令参数相同, 都返回 *Future; XXXWithReturn 的结果直接从 Reply 中取
+*/
+type futureCmdable func(cmd string, args ...interface{}) *Future
+
+// 排队任意命令
+func (c futureCmdable) Do(cmd string, args ...interface{}) *Future {
+	return c(cmd, args...)
+}
+
+/*
+	--------------- strings -----------------------
+*/
+
+func (c futureCmdable) Get(key interface{}) *Future {
+	return c("GET", key)
+}
+
+func (c futureCmdable) Set(key interface{}, value interface{}) *Future {
+	return c("SET", key, value)
+}
+
+func (c futureCmdable) SetEx(key interface{}, seconds int, value interface{}) *Future {
+	return c("SETEX", key, seconds, value)
+}
+
+// Reply().IsSetNxOK() 判断是否设置成功
+func (c futureCmdable) SetNx(key interface{}, value interface{}) *Future {
+	return c("SETNX", key, value)
+}
+
+func (c futureCmdable) Incr(key interface{}) *Future {
+	return c("INCR", key)
+}
+
+func (c futureCmdable) IncrBy(key interface{}, value interface{}) *Future {
+	return c("INCRBY", key, value)
+}
+
+func (c futureCmdable) MGet(keys ...interface{}) *Future {
+	return c("MGET", keys...)
+}
+
+func (c futureCmdable) MSet(kvs ...interface{}) *Future {
+	return c("MSET", kvs...)
+}
+
+/*
+	--------------- hash -----------------------
+*/
+
+// Reply().IsHSetNew() 判断是否新增
+func (c futureCmdable) HSet(key interface{}, member, value interface{}) *Future {
+	return c("HSET", key, member, value)
+}
+
+func (c futureCmdable) HGet(key interface{}, name interface{}) *Future {
+	return c("HGET", key, name)
+}
+
+func (c futureCmdable) HLen(key interface{}) *Future {
+	return c("HLEN", key)
+}
+
+func (c futureCmdable) HMGet(args ...interface{}) *Future {
+	return c("HMGET", args...)
+}
+
+func (c futureCmdable) HMSet(args ...interface{}) *Future {
+	return c("HMSET", args...)
+}
+
+func (c futureCmdable) HGetAll(key interface{}) *Future {
+	return c("HGETALL", key)
+}
+
+func (c futureCmdable) HDel(args ...interface{}) *Future {
+	return c("HDEL", args...)
+}
+
+func (c futureCmdable) HIncBy(key interface{}, field interface{}, increment int64) *Future {
+	return c("HINCRBY", key, field, increment)
+}
+
+/*
+	--------------- list -----------------------
+*/
+
+func (c futureCmdable) RPush(args ...interface{}) *Future {
+	return c("RPUSH", args...)
+}
+
+func (c futureCmdable) LPush(args ...interface{}) *Future {
+	return c("LPUSH", args...)
+}
+
+func (c futureCmdable) LPop(key interface{}) *Future {
+	return c("LPOP", key)
+}
+
+func (c futureCmdable) RPop(key interface{}) *Future {
+	return c("RPOP", key)
+}
+
+func (c futureCmdable) LRange(key interface{}, start, stop interface{}) *Future {
+	return c("LRANGE", key, start, stop)
+}
+
+func (c futureCmdable) LLen(key interface{}) *Future {
+	return c("LLEN", key)
+}
+
+func (c futureCmdable) LTrim(key interface{}, start, end int64) *Future {
+	return c("LTRIM", key, start, end)
+}
+
+func (c futureCmdable) LRem(key interface{}, count int64, value interface{}) *Future {
+	return c("LREM", key, count, value)
+}
+
+func (c futureCmdable) LIndex(key interface{}, index int64) *Future {
+	return c("LINDEX", key, index)
+}
+
+func (c futureCmdable) LSet(key interface{}, idx int64, data interface{}) *Future {
+	return c("LSET", key, idx, data)
+}
+
+func (c futureCmdable) LFront(key interface{}) *Future {
+	return c.LIndex(key, 0)
+}
+
+func (c futureCmdable) LBack(key interface{}) *Future {
+	return c.LIndex(key, -1)
+}
+
+/*
+	--------------- set -----------------------
+*/
+
+func (c futureCmdable) SAdd(args ...interface{}) *Future {
+	return c("SADD", args...)
+}
+
+func (c futureCmdable) SRem(args ...interface{}) *Future {
+	return c("SREM", args...)
+}
+
+func (c futureCmdable) SIsMember(key interface{}, value interface{}) *Future {
+	return c("SISMEMBER", key, value)
+}
+
+func (c futureCmdable) SMembers(key interface{}) *Future {
+	return c("SMEMBERS", key)
+}
+
+func (c futureCmdable) SCard(key interface{}) *Future {
+	return c("SCARD", key)
+}
+
+func (c futureCmdable) SRandMembers(key interface{}, count int) *Future {
+	return c("SRANDMEMBER", key, count)
+}
+
+/*
+	--------------- zset -----------------------
+*/
+
+func (c futureCmdable) ZAdd(args ...interface{}) *Future {
+	return c("ZADD", args...)
+}
+
+func (c futureCmdable) ZCount(key interface{}, min, max float64) *Future {
+	return c("ZCOUNT", key, min, max)
+}
+
+func (c futureCmdable) ZCard(key interface{}) *Future {
+	return c("ZCARD", key)
+}
+
+func (c futureCmdable) ZRank(key interface{}, member interface{}, asc bool) *Future {
+	if !asc {
+		return c("ZREVRANK", key, member)
+	}
+	return c("ZRANK", key, member)
+}
+
+func (c futureCmdable) ZIncBy(key interface{}, increment interface{}, member interface{}) *Future {
+	return c("ZINCRBY", key, increment, member)
+}
+
+func (c futureCmdable) ZScore(key interface{}, item interface{}) *Future {
+	return c("ZSCORE", key, item)
+}
+
+func (c futureCmdable) ZRem(args ...interface{}) *Future {
+	return c("ZREM", args...)
+}
+
+func (c futureCmdable) ZRange(key interface{}, start, end int) *Future {
+	return c("ZRANGE", key, start, end)
+}
+
+func (c futureCmdable) ZRevRange(key interface{}, start, end int) *Future {
+	return c("ZREVRANGE", key, start, end)
+}
+
+func (c futureCmdable) ZRangeWithScore(key interface{}, start, end int) *Future {
+	return c("ZRANGE", key, start, end, "WITHSCORES")
+}
+
+func (c futureCmdable) ZRevRangeWithScore(key interface{}, start, end int) *Future {
+	return c("ZREVRANGE", key, start, end, "WITHSCORES")
+}
+
+func (c futureCmdable) ZRangeByScore(key interface{}, min, max interface{}, limit int) *Future {
+	if limit > 0 {
+		return c("ZRANGEBYSCORE", key, min, max, "LIMIT", 0, limit)
+	}
+	return c("ZRANGEBYSCORE", key, min, max)
+}
+
+func (c futureCmdable) ZRevRangeByScore(key interface{}, min, max interface{}, limit int) *Future {
+	if limit > 0 {
+		return c("ZREVRANGEBYSCORE", key, max, min, "LIMIT", 0, limit)
+	}
+	return c("ZREVRANGEBYSCORE", key, max, min)
+}
+
+// min <= score < max
+func (c futureCmdable) ZRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Future {
+	s := fmt.Sprintf("(%v", max)
+	if limit > 0 {
+		return c("ZRANGEBYSCORE", key, min, s, "WITHSCORES", "LIMIT", 0, limit)
+	}
+	return c("ZRANGEBYSCORE", key, min, s, "WITHSCORES")
+}
+
+func (c futureCmdable) ZRevRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Future {
+	s := fmt.Sprintf("(%v", max)
+	if limit > 0 {
+		return c("ZREVRANGEBYSCORE", key, s, min, "WITHSCORES", "LIMIT", 0, limit)
+	}
+	return c("ZREVRANGEBYSCORE", key, s, min, "WITHSCORES")
+}
+
+func (c futureCmdable) ZRemRangeByScore(key interface{}, min, max int64) *Future {
+	return c("ZREMRANGEBYSCORE", key, min, max)
+}
+
+/*
+	--------------- geo -----------------------
+*/
+
+func (c futureCmdable) GeoAdd(key string, values ...interface{}) *Future {
+	args := make([]interface{}, 0, len(values)+1)
+	args = append(args, key)
+	args = append(args, values...)
+	return c("GEOADD", args...)
+}
+
+func (c futureCmdable) GeoDist(key interface{}, mem1, mem2 uint32, unit string) *Future {
+	return c("GEODIST", key, mem1, mem2, unit)
+}
+
+/*
+	--------------- keys -----------------------
+*/
+
+func (c futureCmdable) Exists(key interface{}) *Future {
+	return c("EXISTS", key)
+}
+
+func (c futureCmdable) Del(keys ...interface{}) *Future {
+	return c("DEL", keys...)
+}
+
+func (c futureCmdable) Expire(expire int64, key interface{}) *Future {
+	return c("EXPIRE", key, expire)
+}
+
+func (c futureCmdable) ExpireAt(expireAt int64, key interface{}) *Future {
+	return c("EXPIREAT", key, expireAt)
+}
+
+func (c futureCmdable) TTL(key interface{}) *Future {
+	return c("TTL", key)
+}
+
+func (c futureCmdable) Keys(pattern interface{}) *Future {
+	return c("KEYS", pattern)
+}
+
+func (c futureCmdable) Publish(channel, value interface{}) *Future {
+	return c("PUBLISH", channel, value)
+}
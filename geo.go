@@ -1,6 +1,9 @@
 package mredis
 
-import redigo "github.com/gomodule/redigo/redis"
+import (
+	"context"
+	redigo "github.com/gomodule/redigo/redis"
+)
 
 /*
 	封装 GEO 的相关功能
@@ -9,17 +12,18 @@ import redigo "github.com/gomodule/redigo/redis"
 /*
 	添加几个
 */
-func (rp *RedisPool) GeoAdd(key string, values ...interface{}) (int64, error) {
+func (c cmdable) GeoAdd(key string, values ...interface{}) (int64, error) {
+	return c.GeoAddCtx(context.Background(), key, values...)
+}
+
+func (c cmdable) GeoAddCtx(ctx context.Context, key string, values ...interface{}) (int64, error) {
 	args := make([]interface{}, 0, len(values)+1)
 	args = append(args, key)
 	args = append(args, values...)
 
 	//args[0] = key
 	//copy(args[1:], values)
-	con := rp.getConn()
-	defer con.Close()
-
-	return redigo.Int64(con.Do("GEOADD", args...))
+	return redigo.Int64(c(ctx, "GEOADD", args...))
 }
 
 /*
@@ -29,16 +33,17 @@ func (rp *RedisPool) GeoAdd(key string, values ...interface{}) (int64, error) {
 	mi for miles.
 	ft for feet.
 */
-func (rp *RedisPool) GeoDist(key interface{}, mem1, mem2 uint32, unit string) (distance float64, e error) {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) GeoDist(key interface{}, mem1, mem2 uint32, unit string) (distance float64, e error) {
+	return c.GeoDistCtx(context.Background(), key, mem1, mem2, unit)
+}
 
-	return redigo.Float64(con.Do("GEODIST", key, mem1, mem2, unit))
+func (c cmdable) GeoDistCtx(ctx context.Context, key interface{}, mem1, mem2 uint32, unit string) (distance float64, e error) {
+	return redigo.Float64(c(ctx, "GEODIST", key, mem1, mem2, unit))
 }
 
 /*func GeoHash()*/
 
-func (rp *RedisPool) GeoPos(mem1 ...uint32) error {
+func (c cmdable) GeoPos(mem1 ...uint32) error {
 	return nil
 }
 
@@ -2,4 +2,9 @@ module github.com/wxiaowar/mredis
 
 go 1.14
 
-require github.com/gomodule/redigo v1.8.1
+require (
+	github.com/gomodule/redigo v1.8.9
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,31 +1,35 @@
 package mredis
 
 import (
+	"context"
 	redigo "github.com/gomodule/redigo/redis"
 )
 
 // O(1)
-func (rp *RedisPool) HSetWithReturn(key interface{}, member, value interface{}) *Reply {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HSetWithReturn(key interface{}, member, value interface{}) *Reply {
+	return c.HSetWithReturnCtx(context.Background(), key, member, value)
+}
 
-	return reply(con.Do("HSET", key, member, value)) // value: 1-设置新key，0-更新已经存在的key
+func (c cmdable) HSetWithReturnCtx(ctx context.Context, key interface{}, member, value interface{}) *Reply {
+	return reply(c(ctx, "HSET", key, member, value)) // value: 1-设置新key，0-更新已经存在的key
 }
 
-func (rp *RedisPool) HSet(key interface{}, member, value interface{}) (e error) {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HSet(key interface{}, member, value interface{}) (e error) {
+	return c.HSetCtx(context.Background(), key, member, value)
+}
 
-	_, e = con.Do("HSET", key, member, value)
+func (c cmdable) HSetCtx(ctx context.Context, key interface{}, member, value interface{}) (e error) {
+	_, e = c(ctx, "HSET", key, member, value)
 	return e
 }
 
 // O(1)
-func (rp *RedisPool) HGet(key interface{}, name interface{}) *Reply {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HGet(key interface{}, name interface{}) *Reply {
+	return c.HGetCtx(context.Background(), key, name)
+}
 
-	value, e := con.Do("HGET", key, name)
+func (c cmdable) HGetCtx(ctx context.Context, key interface{}, name interface{}) *Reply {
+	value, e := c(ctx, "HGET", key, name)
 	if e != nil {
 		return reply(value, e)
 	}
@@ -42,11 +46,12 @@ func (rp *RedisPool) HGet(key interface{}, name interface{}) *Reply {
 }
 
 // o(1)
-func (rp *RedisPool) HLen(key interface{}) (num int64, e error) {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HLen(key interface{}) (num int64, e error) {
+	return c.HLenCtx(context.Background(), key)
+}
 
-	return redigo.Int64(con.Do("HLEN", key))
+func (c cmdable) HLenCtx(ctx context.Context, key interface{}) (num int64, e error) {
+	return redigo.Int64(c(ctx, "HLEN", key))
 }
 
 /*
@@ -54,69 +59,76 @@ func (rp *RedisPool) HLen(key interface{}) (num int64, e error) {
  args: 第一个值必须是key，后续的值都是id
  reply=>{val1, val2, val3...}
 */
-func (rp *RedisPool) HMGet(args ...interface{}) *Reply {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HMGet(args ...interface{}) *Reply {
+	return c.HMGetCtx(context.Background(), args...)
+}
 
-	return reply(con.Do("HMGET", args...))
+func (c cmdable) HMGetCtx(ctx context.Context, args ...interface{}) *Reply {
+	return reply(c(ctx, "HMGET", args...))
 }
 
 /*
  O(N) where N is the number of fields being set
  args: key item value [item2, value2...] 值对
 */
-func (rp *RedisPool) HMSet(args...interface{}) (e error) {
+func (c cmdable) HMSet(args...interface{}) (e error) {
+	return c.HMSetCtx(context.Background(), args...)
+}
+
+func (c cmdable) HMSetCtx(ctx context.Context, args ...interface{}) (e error) {
 	if len(args) % 2 != 1 {
 		panic("invalid HMSet param")
 	}
 
-	con := rp.getConn()
-	defer con.Close()
-
-	_, e = con.Do("HMSET", args...)
+	_, e = c(ctx, "HMSET", args...)
 	return
 }
 
 /*
 reply=>{key1, val1, key2, val2, ...}
 */
-func (rp *RedisPool) HGetAll(key interface{}) *Reply {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HGetAll(key interface{}) *Reply {
+	return c.HGetAllCtx(context.Background(), key)
+}
 
-	return reply(con.Do("HGETALL", key))
+func (c cmdable) HGetAllCtx(ctx context.Context, key interface{}) *Reply {
+	return reply(c(ctx, "HGETALL", key))
 }
 
 /*
 	args: 第一个必须是key，后面的都是id
 */
-func (rp *RedisPool) HDel(args ...interface{}) error {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HDel(args ...interface{}) error {
+	return c.HDelCtx(context.Background(), args...)
+}
 
-	_, e := con.Do("HDEL", args...)
+func (c cmdable) HDelCtx(ctx context.Context, args ...interface{}) error {
+	_, e := c(ctx, "HDEL", args...)
 	return e
 }
 
 // O(n) args: 第一个必须是key，后面的都是id
-func (rp *RedisPool) HDelWithReturn(args ...interface{}) *Reply {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HDelWithReturn(args ...interface{}) *Reply {
+	return c.HDelWithReturnCtx(context.Background(), args...)
+}
 
-	return reply(con.Do("HDEL", args...)) // value: 0-键不存在，>0-删除的键的数量
+func (c cmdable) HDelWithReturnCtx(ctx context.Context, args ...interface{}) *Reply {
+	return reply(c(ctx, "HDEL", args...)) // value: 0-键不存在，>0-删除的键的数量
 }
 
-func (rp *RedisPool) HIncBy(key interface{}, field interface{}, increment int64) (e error) {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HIncBy(key interface{}, field interface{}, increment int64) (e error) {
+	return c.HIncByCtx(context.Background(), key, field, increment)
+}
 
-	_, e = con.Do("HINCRBY", key, field, increment)
+func (c cmdable) HIncByCtx(ctx context.Context, key interface{}, field interface{}, increment int64) (e error) {
+	_, e = c(ctx, "HINCRBY", key, field, increment)
 	return e
 }
 
-func (rp *RedisPool) HIncByWithReturn(key interface{}, field interface{}, increment int64) *Reply {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) HIncByWithReturn(key interface{}, field interface{}, increment int64) *Reply {
+	return c.HIncByWithReturnCtx(context.Background(), key, field, increment)
+}
 
-	return reply(con.Do("HINCRBY", key, field, increment))
+func (c cmdable) HIncByWithReturnCtx(ctx context.Context, key interface{}, field interface{}, increment int64) *Reply {
+	return reply(c(ctx, "HINCRBY", key, field, increment))
 }
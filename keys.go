@@ -1,47 +1,53 @@
 package mredis
 
 import (
+	"context"
 	redigo "github.com/gomodule/redigo/redis"
 )
 
-func (rp *RedisPool) Exists(key interface{}) (bool, error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) Exists(key interface{}) (bool, error) {
+	return c.ExistsCtx(context.Background(), key)
+}
 
-	return redigo.Bool(conn.Do("EXISTS", key))
+func (c cmdable) ExistsCtx(ctx context.Context, key interface{}) (bool, error) {
+	return redigo.Bool(c(ctx, "EXISTS", key))
 }
 
 // 返回值小于1，表示键不存在
-func (rp *RedisPool) DelWithReturn(keys ...interface{}) (int, error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) DelWithReturn(keys ...interface{}) (int, error) {
+	return c.DelWithReturnCtx(context.Background(), keys...)
+}
 
-	return redigo.Int(conn.Do("DEL", keys...))
+func (c cmdable) DelWithReturnCtx(ctx context.Context, keys ...interface{}) (int, error) {
+	return redigo.Int(c(ctx, "DEL", keys...))
 }
 
-func (rp *RedisPool) Del(key ...interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) Del(key ...interface{}) (e error) {
+	return c.DelCtx(context.Background(), key...)
+}
 
-	_, e = conn.Do("DEL", key...)
+func (c cmdable) DelCtx(ctx context.Context, key ...interface{}) (e error) {
+	_, e = c(ctx, "DEL", key...)
 	return
 }
 
 /*
 设置key的有效时间,返回值不等于1，表示键不存在
 */
-func (rp *RedisPool) ExpireWithReturn(expire int64, key interface{}) (int, error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ExpireWithReturn(expire int64, key interface{}) (int, error) {
+	return c.ExpireWithReturnCtx(context.Background(), expire, key)
+}
 
-	return redigo.Int(conn.Do("EXPIRE", key, expire))
+func (c cmdable) ExpireWithReturnCtx(ctx context.Context, expire int64, key interface{}) (int, error) {
+	return redigo.Int(c(ctx, "EXPIRE", key, expire))
 }
 
-func (rp *RedisPool) Expire(expire int64, key interface{}) error {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) Expire(expire int64, key interface{}) error {
+	return c.ExpireCtx(context.Background(), expire, key)
+}
 
-	_, err := conn.Do("EXPIRE", key, expire)
+func (c cmdable) ExpireCtx(ctx context.Context, expire int64, key interface{}) error {
+	_, err := c(ctx, "EXPIRE", key, expire)
 	return err
 }
 
@@ -52,18 +58,20 @@ func (rp *RedisPool) Expire(expire int64, key interface{}) error {
 
 	不存在或者没办法设置，返回0
 */
-func (rp *RedisPool) ExpireAtWithReturn(expireAt int64, key interface{}) (ret int, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ExpireAtWithReturn(expireAt int64, key interface{}) (ret int, e error) {
+	return c.ExpireAtWithReturnCtx(context.Background(), expireAt, key)
+}
 
-	return redigo.Int(conn.Do("EXPIREAT", key, expireAt))
+func (c cmdable) ExpireAtWithReturnCtx(ctx context.Context, expireAt int64, key interface{}) (ret int, e error) {
+	return redigo.Int(c(ctx, "EXPIREAT", key, expireAt))
 }
 
-func (rp *RedisPool) ExpireAt(expireAt int64, key interface{}) error {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ExpireAt(expireAt int64, key interface{}) error {
+	return c.ExpireAtCtx(context.Background(), expireAt, key)
+}
 
-	_, err := conn.Do("EXPIREAT", key, expireAt)
+func (c cmdable) ExpireAtCtx(ctx context.Context, expireAt int64, key interface{}) error {
+	_, err := c(ctx, "EXPIREAT", key, expireAt)
 	return err
 }
 
@@ -151,16 +159,18 @@ func (rp *RedisPool) ExpireAt(expireAt int64, key interface{}) error {
 /*
 获取key的有效时间
 */
-func (rp *RedisPool) TTL(key interface{}) (expire int, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) TTL(key interface{}) (expire int, e error) {
+	return c.TTLCtx(context.Background(), key)
+}
 
-	return redigo.Int(conn.Do("TTL", key))
+func (c cmdable) TTLCtx(ctx context.Context, key interface{}) (expire int, e error) {
+	return redigo.Int(c(ctx, "TTL", key))
 }
 
-func (rp *RedisPool) Keys(pattern interface{}) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) Keys(pattern interface{}) *Reply {
+	return c.KeysCtx(context.Background(), pattern)
+}
 
-	return reply(conn.Do("KEYS", pattern))
+func (c cmdable) KeysCtx(ctx context.Context, pattern interface{}) *Reply {
+	return reply(c(ctx, "KEYS", pattern))
 }
@@ -1,157 +1,185 @@
 package mredis
 
-import redigo "github.com/gomodule/redigo/redis"
+import (
+	"context"
+	redigo "github.com/gomodule/redigo/redis"
+)
 
 //批量插入队尾
 // args : <key, val1, val2, val3...>
-func (rp *RedisPool) RPush(args ...interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) RPush(args ...interface{}) (e error) {
+	return c.RPushCtx(context.Background(), args...)
+}
 
-	_, e = conn.Do("RPUSH", args...)
+func (c cmdable) RPushCtx(ctx context.Context, args ...interface{}) (e error) {
+	_, e = c(ctx, "RPUSH", args...)
 	return
 }
 
-func (rp *RedisPool) RPushWithReturn(args ...interface{}) (llen int64, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) RPushWithReturn(args ...interface{}) (llen int64, e error) {
+	return c.RPushWithReturnCtx(context.Background(), args...)
+}
 
-	return redigo.Int64(conn.Do("RPUSH", args...))
+func (c cmdable) RPushWithReturnCtx(ctx context.Context, args ...interface{}) (llen int64, e error) {
+	return redigo.Int64(c(ctx, "RPUSH", args...))
 }
 
 // 队头弹出队列数据
-func (rp *RedisPool) LPop(key interface{}) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LPop(key interface{}) *Reply {
+	return c.LPopCtx(context.Background(), key)
+}
 
-	return reply(conn.Do("LPOP", key))
+func (c cmdable) LPopCtx(ctx context.Context, key interface{}) *Reply {
+	return reply(c(ctx, "LPOP", key))
 }
 
 //批量插入队头
 // args : <key, val1, val2, val3...>
-func (rp *RedisPool) LPush(args ...interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LPush(args ...interface{}) (e error) {
+	return c.LPushCtx(context.Background(), args...)
+}
 
-	_, e = conn.Do("LPUSH", args...)
+func (c cmdable) LPushCtx(ctx context.Context, args ...interface{}) (e error) {
+	_, e = c(ctx, "LPUSH", args...)
 	return
 }
 
-func (rp *RedisPool) LPushWithReturn(args...interface{}) (llen int64, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LPushWithReturn(args...interface{}) (llen int64, e error) {
+	return c.LPushWithReturnCtx(context.Background(), args...)
+}
 
-	return redigo.Int64(conn.Do("LPUSH", args...))
+func (c cmdable) LPushWithReturnCtx(ctx context.Context, args ...interface{}) (llen int64, e error) {
+	return redigo.Int64(c(ctx, "LPUSH", args...))
 }
 
 //从对尾pop
-func (rp *RedisPool) RPop(key interface{}) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) RPop(key interface{}) *Reply {
+	return c.RPopCtx(context.Background(), key)
+}
 
-	return reply(conn.Do("RPOP", key))
+func (c cmdable) RPopCtx(ctx context.Context, key interface{}) *Reply {
+	return reply(c(ctx, "RPOP", key))
 }
 
 /*
 args: key1, key2, key3..., timeout
 */
-func (rp *RedisPool) BRPop(args ...interface{}) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) BRPop(args ...interface{}) *Reply {
+	return c.BRPopCtx(context.Background(), args...)
+}
 
-	return reply(conn.Do("BRPOP", args...))
+// ctx 取消时连接会被关闭, 阻塞中的 BRPOP 立即返回
+func (c cmdable) BRPopCtx(ctx context.Context, args ...interface{}) *Reply {
+	return reply(c(ctx, "BRPOP", args...))
 }
 
 /*
 args: key1, key2, key3..., timeout
 */
-func (rp *RedisPool) BLPop(args ...interface{}) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) BLPop(args ...interface{}) *Reply {
+	return c.BLPopCtx(context.Background(), args...)
+}
 
-	return reply(conn.Do("BLPOP", args...))
+// ctx 取消时连接会被关闭, 阻塞中的 BLPOP 立即返回
+func (c cmdable) BLPopCtx(ctx context.Context, args ...interface{}) *Reply {
+	return reply(c(ctx, "BLPOP", args...))
 }
 
 /*
 获取队列数据
 */
-func (rp *RedisPool) LRange(key interface{}, start, stop interface{}) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LRange(key interface{}, start, stop interface{}) *Reply {
+	return c.LRangeCtx(context.Background(), key, start, stop)
+}
 
-	return reply(conn.Do("LRANGE", key, start, stop))
+func (c cmdable) LRangeCtx(ctx context.Context, key interface{}, start, stop interface{}) *Reply {
+	return reply(c(ctx, "LRANGE", key, start, stop))
 }
 
 /*
 获取队列长度，如果key不存在，length=0，不会报错。
 */
-func (rp *RedisPool) LLen(key interface{}) (length int64, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LLen(key interface{}) (length int64, e error) {
+	return c.LLenCtx(context.Background(), key)
+}
 
-	return redigo.Int64(conn.Do("LLEN", key))
+func (c cmdable) LLenCtx(ctx context.Context, key interface{}) (length int64, e error) {
+	return redigo.Int64(c(ctx, "LLEN", key))
 }
 
 /*
 剪裁
 */
-func (rp *RedisPool) LTrim(key interface{}, start, end int64) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LTrim(key interface{}, start, end int64) (e error) {
+	return c.LTrimCtx(context.Background(), key, start, end)
+}
 
-	_, e = conn.Do("LTRIM", key, start, end)
+func (c cmdable) LTrimCtx(ctx context.Context, key interface{}, start, end int64) (e error) {
+	_, e = c(ctx, "LTRIM", key, start, end)
 	return
 }
 
 /*
 删除
 */
-func (rp *RedisPool) LRem(key interface{}, count int64, value interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LRem(key interface{}, count int64, value interface{}) (e error) {
+	return c.LRemCtx(context.Background(), key, count, value)
+}
 
-	_, e =conn.Do("LREM", key, count, value)
+func (c cmdable) LRemCtx(ctx context.Context, key interface{}, count int64, value interface{}) (e error) {
+	_, e = c(ctx, "LREM", key, count, value)
 	return
 }
 
-func (rp *RedisPool) LRemWithReturn(key interface{}, count int64, value interface{}) (int, error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LRemWithReturn(key interface{}, count int64, value interface{}) (int, error) {
+	return c.LRemWithReturnCtx(context.Background(), key, count, value)
+}
 
-	return redigo.Int(conn.Do("LREM", key, count, value))
+func (c cmdable) LRemWithReturnCtx(ctx context.Context, key interface{}, count int64, value interface{}) (int, error) {
+	return redigo.Int(c(ctx, "LREM", key, count, value))
 }
 
 /*
 索引元素
 */
-func (rp *RedisPool) LIndex(key interface{}, index int64) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) LIndex(key interface{}, index int64) *Reply {
+	return c.LIndexCtx(context.Background(), key, index)
+}
 
-	return reply(conn.Do("LINDEX", key, index))
+func (c cmdable) LIndexCtx(ctx context.Context, key interface{}, index int64) *Reply {
+	return reply(c(ctx, "LINDEX", key, index))
 }
 
 /*
 更新
 */
-func (rp *RedisPool) LSet(key interface{}, idx int64, data interface{}) (e error) {
-	scon := rp.getConn()
-	defer scon.Close()
+func (c cmdable) LSet(key interface{}, idx int64, data interface{}) (e error) {
+	return c.LSetCtx(context.Background(), key, idx, data)
+}
 
-	_, e = scon.Do("LSET", key, idx, data)
+func (c cmdable) LSetCtx(ctx context.Context, key interface{}, idx int64, data interface{}) (e error) {
+	_, e = c(ctx, "LSET", key, idx, data)
 	return
 }
 
 /*
 获取头部元素
 */
-func (rp *RedisPool) LFront(key interface{}) *Reply {
-	return rp.LIndex(key, 0)
+func (c cmdable) LFront(key interface{}) *Reply {
+	return c.LIndex(key, 0)
+}
+
+func (c cmdable) LFrontCtx(ctx context.Context, key interface{}) *Reply {
+	return c.LIndexCtx(ctx, key, 0)
 }
 
 /*
 获取尾部元素
 */
-func (rp *RedisPool) LBack(key interface{}) *Reply {
-	return rp.LIndex(key, -1)
+func (c cmdable) LBack(key interface{}) *Reply {
+	return c.LIndex(key, -1)
+}
+
+func (c cmdable) LBackCtx(ctx context.Context, key interface{}) *Reply {
+	return c.LIndexCtx(ctx, key, -1)
 }
@@ -0,0 +1,250 @@
+package mredis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownPool   = errors.New("mredis: unknown pool")
+	ErrManagerClosed = errors.New("mredis: manager is closed")
+)
+
+/*
+	PoolConfig 一个命名连接池的配置
+
+	Options 覆盖 URL 中的同名参数(maxActive, readTimeout 等, 见 NewRedisPool), 例如:
+	{
+		"cache":   {"url": "redis://:pwd@10.0.0.1:6379/0", "options": {"maxActive": 64}},
+		"session": {"url": "redis://10.0.0.2:6379/1"}
+	}
+*/
+type PoolConfig struct {
+	URL     string                 `json:"url" yaml:"url"`
+	Options map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// 合并 Options 后的 URL
+func (c PoolConfig) url() (string, error) {
+	if len(c.Options) == 0 {
+		return c.URL, nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return "", err
+	}
+
+	values := u.Query()
+	for k, v := range c.Options {
+		values.Set(k, fmt.Sprint(v))
+	}
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+/*
+	Manager 按名字管理多个连接池, 第一次使用时创建, Close 时一起关闭
+*/
+type Manager struct {
+	mu      sync.Mutex
+	configs map[string]PoolConfig
+	pools   map[string]*RedisPool
+	closed  bool
+}
+
+func NewManager(configs map[string]PoolConfig) *Manager {
+	m := &Manager{
+		configs: make(map[string]PoolConfig, len(configs)),
+		pools:   make(map[string]*RedisPool, len(configs)),
+	}
+	for name, c := range configs {
+		m.configs[name] = c
+	}
+	return m
+}
+
+// 根据扩展名按 JSON(.json) 或 YAML(.yaml, .yml) 解析, 内容为 名字 -> PoolConfig
+func LoadManager(path string) (*Manager, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]PoolConfig)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &configs)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &configs)
+	default:
+		return nil, fmt.Errorf("mredis: unsupported config file: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mredis: parse %s: %v", path, err)
+	}
+
+	return newManagerChecked(configs)
+}
+
+// NewRedisPool 支持的 URL 参数, LoadManagerFromEnv 可以用单独的环境变量设置
+var urlParams = []string{
+	"maxIdle", "maxActive", "idleTimeout", "maxConnLifetime", "healthCheck", "wait",
+	"connectTimeout", "readTimeout", "writeTimeout", "clientName", "db",
+	"protocol", "codec", "sentinelPassword",
+}
+
+/*
+	从环境变量加载, 变量名为 prefix + 名字, 名字转为小写, 值为 redis url:
+	MREDIS_CACHE=redis://10.0.0.1:6379/0?maxActive=64
+	MREDIS_SESSION=redis://10.0.0.2:6379/1
+	LoadManagerFromEnv("MREDIS_") 得到 cache 和 session 两个连接池
+
+	URL 参数也可以用 prefix + 名字 + _ + 参数名 单独设置, 参数名为大写下划线形式, 同 PoolConfig.Options 覆盖 URL 中的值:
+	MREDIS_CACHE_MAX_ACTIVE=64
+	MREDIS_CACHE_READ_TIMEOUT=200ms
+	只支持 urlParams 中的参数, 其他变量仍然作为 URL; 单独设置参数的连接池也必须有 URL 变量
+	值包含 "://" 的变量总是作为 URL, 所以名字以参数结尾的连接池仍然可以配置:
+	MREDIS_SESSION_DB=redis://10.0.0.3:6379 是连接池 session_db, MREDIS_SESSION_DB=2 是 session 的 db 参数
+*/
+func LoadManagerFromEnv(prefix string) (*Manager, error) {
+	configs := make(map[string]PoolConfig)
+	options := make(map[string]map[string]interface{})
+	for _, kv := range os.Environ() {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 || !strings.HasPrefix(kv[:i], prefix) {
+			continue
+		}
+		key, value := kv[len(prefix):i], kv[i+1:]
+		if key == "" {
+			continue
+		}
+
+		if name, param := envParam(key); param != "" && !strings.Contains(value, "://") {
+			name = strings.ToLower(name)
+			if options[name] == nil {
+				options[name] = make(map[string]interface{})
+			}
+			options[name][param] = value
+			continue
+		}
+		name := strings.ToLower(key)
+		c := configs[name]
+		c.URL = value
+		configs[name] = c
+	}
+
+	for name, opts := range options {
+		c := configs[name]
+		c.Options = opts
+		configs[name] = c
+	}
+	return newManagerChecked(configs)
+}
+
+// 拆分 CACHE_MAX_ACTIVE 为 CACHE 和 maxActive, 不是参数时 param 为空
+func envParam(key string) (name, param string) {
+	upper := strings.ToUpper(key)
+	for _, p := range urlParams {
+		suffix := "_" + envName(p)
+		if len(upper) > len(suffix) && strings.HasSuffix(upper, suffix) {
+			return key[:len(key)-len(suffix)], p
+		}
+	}
+	return key, ""
+}
+
+// maxActive -> MAX_ACTIVE
+func envName(param string) string {
+	var b strings.Builder
+	for _, r := range param {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+// 加载时检查配置, 连接池仍然在第一次使用时创建
+func newManagerChecked(configs map[string]PoolConfig) (*Manager, error) {
+	for name, c := range configs {
+		u, err := c.url()
+		if err == nil {
+			_, err = parsePoolOption(u)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mredis: pool %s: %v", name, err)
+		}
+	}
+
+	return NewManager(configs), nil
+}
+
+// 返回名字对应的连接池, 第一次调用时创建
+func (m *Manager) Pool(name string) (*RedisPool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+	if rp, ok := m.pools[name]; ok {
+		return rp, nil
+	}
+
+	c, ok := m.configs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPool, name)
+	}
+	u, err := c.url()
+	if err != nil {
+		return nil, err
+	}
+	rp, err := NewRedisPool(u)
+	if err != nil {
+		return nil, err
+	}
+
+	m.pools[name] = rp
+	return rp, nil
+}
+
+// 配置中的所有名字
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.configs))
+	for name := range m.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 关闭所有已经创建的连接池, 之后 Pool 返回 ErrManagerClosed
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	var err error
+	for _, rp := range m.pools {
+		if e := rp.Close(); e != nil {
+			err = e
+		}
+	}
+	m.pools = make(map[string]*RedisPool)
+	return err
+}
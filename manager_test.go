@@ -0,0 +1,133 @@
+package mredis
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mredis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"pools.json": `{
+			"cache":   {"url": "redis://127.0.0.1:6379/0", "options": {"maxActive": 64, "readTimeout": "200ms"}},
+			"session": {"url": "redis://127.0.0.1:6379/1"}
+		}`,
+		"pools.yaml": `
+cache:
+  url: redis://127.0.0.1:6379/0
+  options:
+    maxActive: 64
+    readTimeout: 200ms
+session:
+  url: redis://127.0.0.1:6379/1
+`,
+	}
+
+	for file, content := range files {
+		path := filepath.Join(dir, file)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := LoadManager(path)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		if names := m.Names(); len(names) != 2 || names[0] != "cache" || names[1] != "session" {
+			t.Errorf("%s: Names = %v", file, names)
+		}
+
+		cache, err := m.Pool("cache")
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if cache.current().MaxActive != 64 {
+			t.Errorf("%s: cache MaxActive = %d, want 64", file, cache.current().MaxActive)
+		}
+		if again, _ := m.Pool("cache"); again != cache {
+			t.Errorf("%s: Pool should return the same pool", file)
+		}
+		if _, err := m.Pool("queue"); !errors.Is(err, ErrUnknownPool) {
+			t.Errorf("%s: Pool(queue) = %v, want ErrUnknownPool", file, err)
+		}
+
+		m.Close()
+		if _, err := m.Pool("cache"); err != ErrManagerClosed {
+			t.Errorf("%s: Pool after Close = %v", file, err)
+		}
+	}
+}
+
+func TestLoadManagerFromEnv(t *testing.T) {
+	os.Setenv("MREDIS_TEST_CACHE", "redis://127.0.0.1:6379/0?maxActive=7")
+	defer os.Unsetenv("MREDIS_TEST_CACHE")
+
+	m, err := LoadManagerFromEnv("MREDIS_TEST_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	rp, err := m.Pool("cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp.current().MaxActive != 7 {
+		t.Errorf("MaxActive = %d, want 7", rp.current().MaxActive)
+	}
+
+	os.Setenv("MREDIS_TEST_CACHE_MAX_ACTIVE", "9")
+	os.Setenv("MREDIS_TEST_CACHE_READ_TIMEOUT", "150ms")
+	defer os.Unsetenv("MREDIS_TEST_CACHE_MAX_ACTIVE")
+	defer os.Unsetenv("MREDIS_TEST_CACHE_READ_TIMEOUT")
+
+	m2, err := LoadManagerFromEnv("MREDIS_TEST_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m2.Close()
+	if names := m2.Names(); len(names) != 1 || names[0] != "cache" {
+		t.Errorf("Names = %v, want [cache]", names)
+	}
+	if rp, err := m2.Pool("cache"); err != nil || rp.current().MaxActive != 9 {
+		t.Errorf("MAX_ACTIVE override = %v, %v", rp, err)
+	}
+
+	// 值为 URL 时是名字以参数结尾的连接池, 否则是参数
+	os.Setenv("MREDIS_TEST_CACHE_DB", "2")
+	os.Setenv("MREDIS_TEST_SESSION_DB", "redis://127.0.0.1:6379/1")
+	m3, err := LoadManagerFromEnv("MREDIS_TEST_")
+	os.Unsetenv("MREDIS_TEST_CACHE_DB")
+	os.Unsetenv("MREDIS_TEST_SESSION_DB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m3.Close()
+	if names := m3.Names(); len(names) != 2 || names[0] != "cache" || names[1] != "session_db" {
+		t.Errorf("Names = %v, want [cache session_db]", names)
+	}
+	if rp, err := m3.Pool("cache"); err != nil || rp.opt.Conn.DB != 2 {
+		t.Errorf("DB override = %v, %v", rp, err)
+	}
+
+	os.Setenv("MREDIS_TEST_QUEUE_MAX_IDLE", "3")
+	if _, err := LoadManagerFromEnv("MREDIS_TEST_"); err == nil {
+		t.Error("expected error for options without url")
+	}
+	os.Unsetenv("MREDIS_TEST_QUEUE_MAX_IDLE")
+
+	os.Setenv("MREDIS_TEST_BAD", "http://127.0.0.1")
+	defer os.Unsetenv("MREDIS_TEST_BAD")
+	if _, err := LoadManagerFromEnv("MREDIS_TEST_"); err == nil {
+		t.Error("expected error for invalid url")
+	}
+}
@@ -1,8 +1,21 @@
 package mredis
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
 )
 
 var p *RedisPool
@@ -32,4 +45,326 @@ func TestNewPool(t *testing.T) {
 
 	r2, err := p.HGet( mk, "key_1").Int64()
 	fmt.Printf("hget %v-%v\n", r2, err)
-}
\ No newline at end of file
+}
+
+// 连接池已满时, ctx 超时应该放弃等待
+func TestGetCtxPoolWaitTimeout(t *testing.T) {
+	rp := newRedisPool(&redis.Pool{
+		MaxActive: 1,
+		Wait:      true,
+		Dial: func() (redis.Conn, error) {
+			c, _ := net.Pipe()
+			return redis.NewConn(c, 0, 0), nil
+		},
+	}, nil)
+	defer rp.Close()
+
+	held := rp.current().Get()
+	defer held.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := rp.GetCtx(ctx, "key").Error(); err != context.DeadlineExceeded {
+		t.Fatalf("GetCtx error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestParsePoolOption(t *testing.T) {
+	opt, err := parsePoolOption("redis://u:p@127.0.0.1:6379/2?maxIdle=2&maxActive=4&idleTimeout=30&maxConnLifetime=1m&healthCheck=0&wait=false&readTimeout=500ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opt.MaxIdle != 2 || opt.MaxActive != 4 || opt.Address != "127.0.0.1:6379" {
+		t.Errorf("unexpected pool size/address: %+v", opt)
+	}
+	if opt.IdleTimeout != 30*time.Second || opt.MaxConnLifetime != time.Minute || opt.HealthCheck != 0 || opt.Wait {
+		t.Errorf("unexpected pool tuning: %+v", opt)
+	}
+
+	// db 参数覆盖 path 中的数据库
+	for rawurl, want := range map[string]int{
+		"redis://localhost?db=3":    3,
+		"redis://localhost/1?db=4":  4,
+		"rediss://localhost:6380/5": 5,
+	} {
+		if opt, err := parsePoolOption(rawurl); err != nil || opt.Conn.DB != want {
+			t.Errorf("parsePoolOption(%q) db = %v, %v, want %d", rawurl, opt, err, want)
+		}
+	}
+
+	opt, err = parsePoolOption("redis://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.IdleTimeout != IdleTimeoutDefault || opt.HealthCheck != HealthCheckDefault || !opt.Wait {
+		t.Errorf("unexpected defaults: %+v", opt)
+	}
+
+	for _, bad := range []string{
+		"redis://localhost?readTimeout=abc",
+		"redis://localhost?idleTimeout=-1s",
+		"redis://localhost?wait=maybe",
+		"unix://",
+		"unix:///tmp/redis.sock?db=x",
+		"redis://localhost?db=x",
+	} {
+		if _, err := parsePoolOption(bad); err == nil {
+			t.Errorf("parsePoolOption(%q) expected error", bad)
+		}
+	}
+}
+
+// Redis 6 以下不支持 AUTH user password, 退回到 AUTH password
+func TestLegacyAuth(t *testing.T) {
+	var mu sync.Mutex
+	var auths []string
+	srv := newFakeRedis(t, func(args []string) string {
+		if args[0] == "AUTH" {
+			mu.Lock()
+			auths = append(auths, strings.Join(args[1:], " "))
+			mu.Unlock()
+			if len(args) != 2 {
+				return "-ERR wrong number of arguments for 'auth' command\r\n"
+			}
+			return "+OK\r\n"
+		}
+		return bulk("v")
+	})
+	defer srv.Close()
+
+	rp, err := NewRedisPool("redis://user:1234@" + srv.Addr().String() + "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if v, err := rp.Get("k").String(); err != nil || v != "v" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(auths, ",") != "user 1234,1234" {
+		t.Errorf("AUTH args = %q, want [user 1234, 1234]", auths)
+	}
+}
+
+func TestUnixSocketURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mredis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "redis.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+
+	var db string
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveFake(c, func(args []string) string {
+				if args[0] == "SELECT" {
+					db = args[1]
+					return "+OK\r\n"
+				}
+				return bulk(args[1])
+			})
+		}
+	}()
+
+	opt, err := parsePoolOption("redis+unix://:pwd@" + path + "?db=2&maxActive=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.Network != "unix" || opt.Address != path || opt.MaxActive != 10 {
+		t.Errorf("unexpected unix option: %+v", opt)
+	}
+
+	rp, err := NewRedisPool("unix://" + path + "?db=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if v, err := rp.Get("echo").String(); err != nil || v != "echo" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+	if db != "2" {
+		t.Errorf("SELECT %q, want 2", db)
+	}
+}
+
+// fakeRedis 本地 RESP 服务, handler 根据命令返回原始协议数据
+type fakeRedis struct {
+	net.Listener
+}
+
+func newFakeRedis(t *testing.T, handler func(args []string) string) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveFake(c, handler)
+		}
+	}()
+
+	return &fakeRedis{Listener: l}
+}
+
+func serveFake(c net.Conn, handler func(args []string) string) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil || len(line) < 3 || line[0] != '*' {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+
+		args := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			line, err := r.ReadString('\n')
+			if err != nil || len(line) < 3 || line[0] != '$' {
+				return
+			}
+			size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			arg := make([]byte, size+2)
+			if _, err := io.ReadFull(r, arg); err != nil {
+				return
+			}
+			args = append(args, string(arg[:size]))
+		}
+
+		if _, err := c.Write([]byte(handler(args))); err != nil || args[0] == "QUIT" {
+			return
+		}
+	}
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func array(items ...string) string {
+	return "*" + strconv.Itoa(len(items)) + "\r\n" + strings.Join(items, "")
+}
+
+func TestNewRedisPoolWithOptions(t *testing.T) {
+	srv := newFakeRedis(t, func(args []string) string {
+		return bulk(args[1])
+	})
+	defer srv.Close()
+
+	dials := 0
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(),
+		WithMaxActive(1),
+		WithDialer(func(ctx context.Context, network, address string, options ...redis.DialOption) (redis.Conn, error) {
+			dials++
+			return redis.DialContext(ctx, network, address, options...)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	for i := 0; i < 3; i++ {
+		if v, err := rp.Get("echo").String(); err != nil || v != "echo" {
+			t.Fatalf("Get = %q, %v", v, err)
+		}
+	}
+	if dials != 1 {
+		t.Errorf("dials = %d, want 1", dials)
+	}
+}
+
+func TestDo(t *testing.T) {
+	srv := newFakeRedis(t, func(args []string) string {
+		switch args[0] {
+		case "XADD":
+			return bulk("1-0")
+		case "OBJECT":
+			return "-ERR no such key\r\n"
+		}
+		return "$-1\r\n"
+	})
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if id, err := rp.Do("XADD", "stream", "*", "field", "value").String(); err != nil || id != "1-0" {
+		t.Errorf("XADD = %q, %v", id, err)
+	}
+	if _, ok := rp.Do("OBJECT", "ENCODING", "missing").Error().(redis.Error); !ok {
+		t.Error("OBJECT should return a redis error")
+	}
+	if _, err := rp.DoCtx(context.Background(), "GET", "missing").String(); err != redis.ErrNil {
+		t.Errorf("GET missing = %v, want ErrNil", err)
+	}
+	if rp.Stats().Commands != 3 {
+		t.Errorf("Commands = %d, want 3", rp.Stats().Commands)
+	}
+}
+
+func TestNewRedisPoolFromPool(t *testing.T) {
+	srv := newFakeRedis(t, func(args []string) string {
+		return bulk(args[1])
+	})
+	defer srv.Close()
+
+	rp := NewRedisPoolFromPool(&redis.Pool{
+		Dial: func() (redis.Conn, error) { return redis.Dial("tcp", srv.Addr().String()) },
+	})
+	defer rp.Close()
+
+	if v, err := rp.Get("key").String(); err != nil || v != "key" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+}
+
+// 兼容以前直接使用内嵌 redis.Pool 的代码
+func TestPoolAccessor(t *testing.T) {
+	srv := newFakeRedis(t, func(args []string) string { return bulk("v") })
+	defer srv.Close()
+
+	rp, err := NewRedisPool("redis://" + srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	conn := rp.Pool().Get()
+	v, err := redis.String(conn.Do("GET", "k"))
+	conn.Close()
+	if err != nil || v != "v" {
+		t.Errorf("Pool().Get() GET = %q, %v", v, err)
+	}
+
+	if err := rp.Reconfigure("redis://" + srv.Addr().String() + "?maxActive=5"); err != nil {
+		t.Fatal(err)
+	}
+	if n := rp.Pool().MaxActive; n != 5 {
+		t.Errorf("Pool().MaxActive = %d, want 5 after Reconfigure", n)
+	}
+}
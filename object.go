@@ -0,0 +1,199 @@
+package mredis
+
+import (
+	"context"
+	"fmt"
+	redigo "github.com/gomodule/redigo/redis"
+	"reflect"
+)
+
+/*
+	objects 通过 Codec 读写 Go 对象的命令, 内嵌在 RedisPool 等客户端中,
+	codec 返回客户端当前的默认 codec, 每次调用时读取, Reconfigure 修改后立即生效
+
+	rp.SetObject("user:1", &u)
+	err := rp.GetObject("user:1").Decode(&u)
+
+	rp.LPushObject("events", e1, e2)
+	var events []Event
+	err := rp.LRangeObjects("events", 0, -1).Decode(&events)
+*/
+type objects struct {
+	c     cmdable
+	codec func() Codec
+}
+
+func (o objects) defaultCodec() Codec {
+	if o.codec == nil {
+		return nil
+	}
+	return o.codec()
+}
+
+// 固定使用 c
+func staticCodec(c Codec) func() Codec {
+	return func() Codec { return c }
+}
+
+func (o objects) encode(ctx context.Context, values ...interface{}) ([]interface{}, error) {
+	codec := codecFrom(ctx, o.defaultCodec())
+	encoded := make([]interface{}, len(values))
+	for i, v := range values {
+		data, err := codec.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = data
+	}
+	return encoded, nil
+}
+
+// r.Decode 使用本次调用的 codec
+func (o objects) withCodec(ctx context.Context, r *Reply) *Reply {
+	r.codec = codecFrom(ctx, o.defaultCodec())
+	return r
+}
+
+func (o objects) SetObject(key interface{}, v interface{}) error {
+	return o.SetObjectCtx(context.Background(), key, v)
+}
+
+func (o objects) SetObjectCtx(ctx context.Context, key interface{}, v interface{}) error {
+	values, err := o.encode(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.c(ctx, "SET", key, values[0])
+	return err
+}
+
+// key 不存在时 Decode 返回 redigo.ErrNil
+func (o objects) GetObject(key interface{}) *Reply {
+	return o.GetObjectCtx(context.Background(), key)
+}
+
+func (o objects) GetObjectCtx(ctx context.Context, key interface{}) *Reply {
+	return o.withCodec(ctx, reply(o.c(ctx, "GET", key)))
+}
+
+func (o objects) HSetObject(key interface{}, field interface{}, v interface{}) error {
+	return o.HSetObjectCtx(context.Background(), key, field, v)
+}
+
+func (o objects) HSetObjectCtx(ctx context.Context, key interface{}, field interface{}, v interface{}) error {
+	values, err := o.encode(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.c(ctx, "HSET", key, field, values[0])
+	return err
+}
+
+func (o objects) HGetObject(key interface{}, field interface{}) *Reply {
+	return o.HGetObjectCtx(context.Background(), key, field)
+}
+
+func (o objects) HGetObjectCtx(ctx context.Context, key interface{}, field interface{}) *Reply {
+	return o.withCodec(ctx, reply(o.c(ctx, "HGET", key, field)))
+}
+
+func (o objects) LPushObject(key interface{}, values ...interface{}) error {
+	return o.LPushObjectCtx(context.Background(), key, values...)
+}
+
+func (o objects) LPushObjectCtx(ctx context.Context, key interface{}, values ...interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	encoded, err := o.encode(ctx, values...)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.c(ctx, "LPUSH", append([]interface{}{key}, encoded...)...)
+	return err
+}
+
+// 结果通过 Decode(&slice) 解码
+func (o objects) LRangeObjects(key interface{}, start, stop int) *Reply {
+	return o.LRangeObjectsCtx(context.Background(), key, start, stop)
+}
+
+func (o objects) LRangeObjectsCtx(ctx context.Context, key interface{}, start, stop int) *Reply {
+	return o.withCodec(ctx, reply(o.c(ctx, "LRANGE", key, start, stop)))
+}
+
+/*
+	Decode 用 codec 解码结果: 单个值解码到 v; 数组结果(LRANGE, MGET 等)时 v 为 *[]T, 逐个解码,
+	数组中的 nil 解码成 T 的零值; 结果为 nil 时返回 redigo.ErrNil
+
+	对象命令返回的 Reply 使用对应的 codec, 其他 Reply 使用 JSONCodec
+*/
+func (r *Reply) Decode(v interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+
+	codec := r.codec
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	switch raw := r.Raw.(type) {
+	case nil:
+		return redigo.ErrNil
+	case []interface{}:
+		return decodeSlice(codec, raw, v)
+	}
+
+	data, err := redigo.Bytes(r.Raw, nil)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(data, v)
+}
+
+func decodeSlice(codec Codec, values []interface{}, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("mredis: Decode array reply into %T, need a pointer to slice", v)
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	result := reflect.MakeSlice(slice.Type(), len(values), len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+
+		data, err := redigo.Bytes(value, nil)
+		if err != nil {
+			return err
+		}
+		elem, err := decodeElem(codec, elemType, data)
+		if err != nil {
+			return err
+		}
+		result.Index(i).Set(elem)
+	}
+	slice.Set(result)
+	return nil
+}
+
+// 解码成 elemType 类型的值; []*T 时直接解码到新的 *T, protobuf 等要求参数本身实现接口
+func decodeElem(codec Codec, elemType reflect.Type, data []byte) (reflect.Value, error) {
+	elem := reflect.New(elemType)
+	target := elem.Interface()
+	if elemType.Kind() == reflect.Ptr {
+		elem.Elem().Set(reflect.New(elemType.Elem()))
+		target = elem.Elem().Interface()
+	}
+	if err := codec.Unmarshal(data, target); err != nil {
+		return reflect.Value{}, err
+	}
+	return elem.Elem(), nil
+}
@@ -0,0 +1,207 @@
+package mredis
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type testEvent struct {
+	ID   int
+	Name string
+}
+
+func newFakeObjects(t *testing.T) *fakeRedis {
+	var mu sync.Mutex
+	kv := make(map[string]string)
+	lists := make(map[string][]string)
+	return newFakeRedis(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch args[0] {
+		case "SET":
+			kv[args[1]] = args[2]
+			return "+OK\r\n"
+		case "GET":
+			v, ok := kv[args[1]]
+			if !ok {
+				return "$-1\r\n"
+			}
+			return bulk(v)
+		case "HSET":
+			kv[args[1]+"/"+args[2]] = args[3]
+			return ":1\r\n"
+		case "HGET":
+			return bulk(kv[args[1]+"/"+args[2]])
+		case "LPUSH":
+			for _, v := range args[2:] {
+				lists[args[1]] = append([]string{v}, lists[args[1]]...)
+			}
+			return ":1\r\n"
+		case "LRANGE":
+			items := make([]string, 0, len(lists[args[1]]))
+			for _, v := range lists[args[1]] {
+				items = append(items, bulk(v))
+			}
+			return array(items...)
+		}
+		return "+OK\r\n"
+	})
+}
+
+func TestObjects(t *testing.T) {
+	srv := newFakeObjects(t)
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(), WithCodec(GobCodec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	var e testEvent
+	if err := rp.GetObject("missing").Decode(&e); err != redis.ErrNil {
+		t.Errorf("Decode missing = %v, want ErrNil", err)
+	}
+
+	if err := rp.SetObject("event", testEvent{ID: 1, Name: "created"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.GetObject("event").Decode(&e); err != nil || e.ID != 1 || e.Name != "created" {
+		t.Errorf("GetObject = %+v, %v", e, err)
+	}
+	// 连接池设置为 gob, 按 JSON 解码应当失败
+	if err := rp.Get("event").Decode(&e); err == nil {
+		t.Error("gob value decoded as JSON")
+	}
+
+	ctx := UseCodec(context.Background(), MsgpackCodec)
+	if err := rp.HSetObjectCtx(ctx, "events", "2", &testEvent{ID: 2, Name: "updated"}); err != nil {
+		t.Fatal(err)
+	}
+	var e2 testEvent
+	if err := rp.HGetObjectCtx(ctx, "events", "2").Decode(&e2); err != nil || e2.ID != 2 || e2.Name != "updated" {
+		t.Errorf("HGetObject = %+v, %v", e2, err)
+	}
+
+	if err := rp.LPushObject("log", testEvent{ID: 3}, testEvent{ID: 4}); err != nil {
+		t.Fatal(err)
+	}
+	var events []testEvent
+	if err := rp.LRangeObjects("log", 0, -1).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].ID != 4 || events[1].ID != 3 {
+		t.Errorf("LRangeObjects = %+v", events)
+	}
+
+	err = rp.WithConn(context.Background(), func(s *Session) error {
+		var e testEvent
+		if err := s.GetObject("event").Decode(&e); err != nil || e.ID != 1 {
+			t.Errorf("Session GetObject = %+v, %v", e, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestObjectsReconfigureCodec(t *testing.T) {
+	srv := newFakeObjects(t)
+	defer srv.Close()
+
+	rp, err := NewRedisPool("redis://" + srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if err := rp.Reconfigure("redis://" + srv.Addr().String() + "?codec=msgpack"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.SetObject("event", testEvent{ID: 6}); err != nil {
+		t.Fatal(err)
+	}
+	var e testEvent
+	if err := rp.Get("event").Decode(&e); err == nil {
+		t.Error("value still encoded as JSON after Reconfigure")
+	}
+
+	err = rp.WithConn(context.Background(), func(s *Session) error {
+		var e testEvent
+		if err := s.GetObject("event").Decode(&e); err != nil || e.ID != 6 {
+			t.Errorf("Session GetObject = %+v, %v", e, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	for name, c := range map[string]Codec{"json": JSONCodec, "gob": GobCodec, "msgpack": MsgpackCodec} {
+		data, err := c.Marshal(testEvent{ID: 5, Name: name})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var e testEvent
+		if err := c.Unmarshal(data, &e); err != nil || e.ID != 5 || e.Name != name {
+			t.Errorf("%s: Unmarshal = %+v, %v", name, e, err)
+		}
+	}
+
+	data, err := ProtobufCodec.Marshal(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []*wrapperspb.StringValue
+	if err := (&Reply{Raw: []interface{}{data}, codec: ProtobufCodec}).Decode(&msgs); err != nil || len(msgs) != 1 || msgs[0].GetValue() != "hello" {
+		t.Errorf("protobuf Decode = %v, %v", msgs, err)
+	}
+	if _, err := ProtobufCodec.Marshal(testEvent{}); err == nil {
+		t.Error("protobuf accepted a non-proto value")
+	}
+
+	opt, err := parsePoolOption("redis://localhost?codec=msgpack")
+	if err != nil || opt.Codec != MsgpackCodec {
+		t.Errorf("codec param = %v, %v", opt, err)
+	}
+	if _, err := parsePoolOption("redis://localhost?codec=xml"); err == nil {
+		t.Error("unknown codec accepted")
+	}
+}
+
+func TestClientCodecs(t *testing.T) {
+	primary, err := NewRedisPoolWithOptions("127.0.0.1:6379", WithCodec(GobCodec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica, err := NewRedisPoolWithOptions("127.0.0.1:6380", WithCodec(MsgpackCodec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rw := NewReadWriteClientWithPools(primary, []*RedisPool{replica})
+	if c := rw.defaultCodec(); c != GobCodec {
+		t.Errorf("ReadWriteClient codec = %T, want primary's gob", c)
+	}
+	rw = NewReadWriteClientWithPools(primary, []*RedisPool{replica}, WithReadWriteCodec(MsgpackCodec))
+	if c := rw.defaultCodec(); c != MsgpackCodec {
+		t.Errorf("WithReadWriteCodec = %T, want msgpack", c)
+	}
+	defer rw.Close()
+
+	shards := map[string]*RedisPool{"a": primary}
+	if c := NewShardedPool(shards).defaultCodec(); c != nil {
+		t.Errorf("ShardedPool codec = %T, want default", c)
+	}
+	if c := NewShardedPool(shards, WithShardedCodec(GobCodec)).defaultCodec(); c != GobCodec {
+		t.Errorf("WithShardedCodec = %T, want gob", c)
+	}
+}
@@ -0,0 +1,261 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"github.com/gomodule/redigo/redis"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DialFunc 创建一条新连接, 可以替换成自定义的拨号、测试用的假连接或者带监控的连接
+type DialFunc func(ctx context.Context, network, address string, options ...redis.DialOption) (redis.Conn, error)
+
+type Option func(opt *poolOption)
+
+func WithMaxIdle(n int) Option {
+	return func(opt *poolOption) { opt.MaxIdle = n }
+}
+
+func WithMaxActive(n int) Option {
+	return func(opt *poolOption) { opt.MaxActive = n }
+}
+
+func WithIdleTimeout(d time.Duration) Option {
+	return func(opt *poolOption) { opt.IdleTimeout = d }
+}
+
+func WithMaxConnLifetime(d time.Duration) Option {
+	return func(opt *poolOption) { opt.MaxConnLifetime = d }
+}
+
+// 空闲超过 d 的连接借出前先 PING, 0 表示不检查
+func WithHealthCheck(d time.Duration) Option {
+	return func(opt *poolOption) { opt.HealthCheck = d }
+}
+
+// 连接数达到 MaxActive 时是否等待
+func WithWait(wait bool) Option {
+	return func(opt *poolOption) { opt.Wait = wait }
+}
+
+func WithNetwork(network string) Option {
+	return func(opt *poolOption) { opt.Network = network }
+}
+
+// 追加 redis.DialOption, 如 redis.DialKeepAlive; 只用于 RESP2 连接, RESP3 时创建连接池返回错误
+func WithDialOptions(options ...redis.DialOption) Option {
+	return func(opt *poolOption) { opt.Options = append(opt.Options, options...) }
+}
+
+func WithDialer(dial DialFunc) Option {
+	return func(opt *poolOption) { opt.Dial = dial }
+}
+
+// NewRedisPool 使用, 整体替换成从 URL 解析出来的配置
+func withPoolOption(p *poolOption) Option {
+	return func(opt *poolOption) { *opt = *p }
+}
+
+/*
+	NewRedisPoolWithOptions("127.0.0.1:6379",
+		WithMaxActive(64),
+		WithDialOptions(redis.DialPassword("secret"), redis.DialDatabase(1)),
+	)
+*/
+func NewRedisPoolWithOptions(addr string, opts ...Option) (*RedisPool, error) {
+	opt := newPoolOption(addr)
+	for _, o := range opts {
+		o(opt)
+	}
+
+	r, err := buildPool(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := newRedisPool(r, opt.Sentinel)
+	rp.opt = opt
+	rp.ownSentinel = opt.ownSentinel
+	rp.wrapDial(r)
+
+	if opt.PingInterval > 0 {
+		rp.health = newHealthChecker(opt.PingThreshold)
+		go rp.health.run(rp, opt.PingInterval)
+	}
+	if opt.AutoPipelineWindow > 0 {
+		rp.batch = newAutoPipeline(rp, opt.AutoPipelineWindow, opt.AutoPipelineBatch)
+		go rp.batch.run()
+	}
+
+	return rp, nil
+}
+
+/*
+	NewRedisPoolFromPool 接管已经创建好的 redis.Pool(自定义 Dial、TestOnBorrow 等), Close 时关闭 p;
+	代替以前的 &RedisPool{Pool: p}, 直接构造的 RedisPool 没有命令方法, 已经不能使用
+*/
+func NewRedisPoolFromPool(p *redis.Pool) *RedisPool {
+	rp := newRedisPool(p, nil)
+	rp.wrapDial(p)
+	return rp
+}
+
+// 默认配置
+func newPoolOption(addr string) *poolOption {
+	return &poolOption{
+		MaxIdle:      IdleDefault,
+		MaxActive:    ActiveDefault,
+		IdleTimeout:  IdleTimeoutDefault,
+		HealthCheck:  HealthCheckDefault,
+		Wait:         true,
+		Address:      addr,
+		WatchRetries: WatchRetriesDefault,
+		WatchBackoff: WatchBackoffDefault,
+	}
+}
+
+// 根据配置创建 redis.Pool, 有 sentinel 时同时启动 sentinel 订阅
+/*
+	legacyAuthOptions 去掉用户名的 DialOption, 用于 Redis 6 以下的服务端:
+	以前 URL 中的 user 被忽略, 只发送 AUTH password; 现在先发送 AUTH user password,
+	服务端不支持两个参数时用这里的参数重新连接. 没有设置用户名或者使用 RESP3 时返回 nil
+*/
+func legacyAuthOptions(opt *poolOption) []redis.DialOption {
+	if opt.Protocol == ProtocolRESP3 || opt.Conn.Username == "" || opt.Conn.Password == "" {
+		return nil
+	}
+	conf := opt.Conn
+	conf.Username = ""
+	return append(conf.dialOptions(), opt.Options...)
+}
+
+// Redis 6 以下对 AUTH user password 返回 ERR wrong number of arguments for 'auth' command
+func isLegacyAuthError(err error) bool {
+	e, ok := err.(redis.Error)
+	return ok && strings.Contains(strings.ToLower(string(e)), "wrong number of arguments")
+}
+
+func buildPool(opt *poolOption) (*redis.Pool, error) {
+	if opt.Address == "" && opt.Sentinel == nil {
+		return nil, errors.New("redis address is empty")
+	}
+	if opt.Network == "" {
+		opt.Network = "tcp"
+	}
+
+	// 不修改 opt.Dial, Reconfigure 继承的是用户设置的拨号函数
+	dial := opt.Dial
+	if opt.Protocol == ProtocolRESP3 {
+		// RESP3 连接自己完成握手, 不能使用 redigo 的 DialOption 和拨号函数
+		if opt.Dial != nil {
+			return nil, errors.New("mredis: WithDialer cannot be used with RESP3, use WithNetDialer")
+		}
+		if len(opt.Options) > 0 {
+			return nil, errors.New("mredis: WithDialOptions cannot be used with RESP3, use WithAuth, WithDatabase, WithTLSConfig")
+		}
+		dial = dialRESP3(opt.Conn, opt.OnPush)
+	} else if dial == nil {
+		dial = redis.DialContext
+	}
+
+	options := append(opt.Conn.dialOptions(), opt.Options...)
+	legacy := legacyAuthOptions(opt)
+	r := &redis.Pool{
+		MaxIdle:         opt.MaxIdle,
+		MaxActive:       opt.MaxActive,
+		IdleTimeout:     opt.IdleTimeout,
+		MaxConnLifetime: opt.MaxConnLifetime,
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			c, err := dial(ctx, opt.Network, opt.Address, options...)
+			if err != nil && legacy != nil && isLegacyAuthError(err) {
+				return dial(ctx, opt.Network, opt.Address, legacy...)
+			}
+			return c, err
+		},
+
+		Wait: opt.Wait,
+	}
+
+	if opt.HealthCheck > 0 {
+		r.TestOnBorrow = func(c redis.Conn, t time.Time) error {
+			if time.Since(t) < opt.HealthCheck {
+				return nil
+			}
+
+			_, err := c.Do("PING")
+			return err
+		}
+	}
+
+	if s := opt.Sentinel; s != nil {
+		r.DialContext = func(ctx context.Context) (redis.Conn, error) {
+			return s.dial(ctx, dial, opt.Network, options...)
+		}
+		r.TestOnBorrow = s.testOnBorrow(opt.HealthCheck)
+		s.start()
+	}
+
+	return r, nil
+}
+
+// 统计 r 建立连接失败的次数, 新连接预加载已注册的 lua 脚本, 并包装成 dialedConn
+func (rp *RedisPool) wrapDial(r *redis.Pool) {
+	dial := r.DialContext
+	if dial == nil {
+		d := r.Dial
+		dial = func(context.Context) (redis.Conn, error) { return d() }
+	}
+	r.DialContext = func(ctx context.Context) (redis.Conn, error) {
+		c, err := dial(ctx)
+		if err != nil {
+			atomic.AddInt64(&rp.stats.dialFailures, 1)
+			return nil, err
+		}
+
+		rp.scripts.preload(c)
+		return &dialedConn{wrappedConn: wrappedConn{c}}, nil
+	}
+
+	// TestOnBorrow 仍然拿到 dial 返回的连接, sentinel 等需要判断连接类型
+	if test := r.TestOnBorrow; test != nil {
+		r.TestOnBorrow = func(c redis.Conn, t time.Time) error {
+			if d, ok := c.(*dialedConn); ok {
+				c = d.Conn
+			}
+			return test(c, t)
+		}
+	}
+}
+
+type forceCloseKey struct{}
+
+// wrapDial 创建的连接, 收到 forceClose 的标记时关闭底层连接
+type dialedConn struct {
+	wrappedConn
+	closed int32
+}
+
+func (c *dialedConn) DoContext(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	if ctx.Value(forceCloseKey{}) != nil {
+		atomic.StoreInt32(&c.closed, 1)
+		return nil, c.Conn.Close()
+	}
+	return c.wrappedConn.DoContext(ctx, cmd, args...)
+}
+
+func (c *dialedConn) Err() error {
+	if atomic.LoadInt32(&c.closed) == 1 {
+		return errConnClosed
+	}
+	return c.Conn.Err()
+}
+
+/*
+	关闭 RedisPool 借出的连接的底层连接, 归还时连接池直接丢弃, 不依赖服务端的行为;
+	redis.Pool 借出的连接只转发 DoContext, 通过 ctx 中的标记传给 dialedConn
+*/
+func forceClose(conn redis.Conn) {
+	redis.DoContext(conn, context.WithValue(context.Background(), forceCloseKey{}, true), "")
+}
@@ -0,0 +1,103 @@
+package mredis
+
+import (
+	"context"
+	"github.com/gomodule/redigo/redis"
+	"time"
+)
+
+/*
+	Pipe 在回调中排队命令, 回调返回后一次性 Send + Flush, 再依次读取结果
+*/
+type Pipe struct {
+	futureCmdable
+	queued []*Future
+}
+
+func newPipe() *Pipe {
+	p := &Pipe{}
+	p.futureCmdable = p.queue
+	return p
+}
+
+func (p *Pipe) queue(cmd string, args ...interface{}) *Future {
+	f := &Future{cmd: cmd, args: args}
+	p.queued = append(p.queued, f)
+	return f
+}
+
+// 已排队的命令数
+func (p *Pipe) Len() int {
+	return len(p.queued)
+}
+
+/*
+	rp.Pipeline(func(p *Pipe) error {
+		name = p.HGet("user:1", "name")
+		score = p.ZScore("rank", 1)
+		return nil
+	})
+	name.Reply().String()
+
+	fn 返回错误时不执行任何命令; redis 返回的错误只影响对应命令的 Reply,
+	网络错误时返回该错误, 未读取到结果的命令 Reply 中也是该错误
+*/
+func (rp *RedisPool) Pipeline(fn func(p *Pipe) error) error {
+	return rp.PipelineCtx(context.Background(), fn)
+}
+
+// ctx 作用于取连接和读取结果
+func (rp *RedisPool) PipelineCtx(ctx context.Context, fn func(p *Pipe) error) error {
+	p := newPipe()
+	if err := fn(p); err != nil {
+		return err
+	}
+	if len(p.queued) == 0 {
+		return nil
+	}
+
+	conn, err := rp.getConnCtx(ctx)
+	if err != nil {
+		rp.stats.observe(0, err)
+		resolveAll(p.queued, err)
+		return err
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	err = p.exec(ctx, conn)
+	rp.stats.observe(time.Since(start), err)
+	return err
+}
+
+func (p *Pipe) exec(ctx context.Context, conn redis.Conn) error {
+	for _, f := range p.queued {
+		if err := conn.Send(f.cmd, f.args...); err != nil {
+			resolveAll(p.queued, err)
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		resolveAll(p.queued, err)
+		return err
+	}
+
+	for i, f := range p.queued {
+		value, err := receiveContext(conn, ctx)
+		if isConnError(err) {
+			resolveAll(p.queued[i:], err)
+			return err
+		}
+		f.resolve(value, err)
+	}
+	return nil
+}
+
+// 同 doContext
+func receiveContext(conn redis.Conn, ctx context.Context) (interface{}, error) {
+	if ctx.Done() == nil {
+		return conn.Receive()
+	}
+
+	return redis.ReceiveContext(conn, ctx)
+}
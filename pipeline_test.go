@@ -0,0 +1,69 @@
+package mredis
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	cmds := 0
+	srv := newFakeRedis(t, func(args []string) string {
+		cmds++
+		switch args[0] {
+		case "HGET":
+			return bulk("alice")
+		case "ZSCORE":
+			return bulk("42")
+		case "INCR":
+			return "-ERR value is not an integer\r\n"
+		}
+		return "+OK\r\n"
+	})
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(), WithHealthCheck(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	var name, score, incr, set *Future
+	err = rp.Pipeline(func(p *Pipe) error {
+		name = p.HGet("user:1", "name")
+		score = p.ZScore("rank", 1)
+		incr = p.Incr("name")
+		set = p.Set("k", "v")
+		if name.Reply().Error() != ErrNotExecuted {
+			t.Error("future resolved before flush")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := name.Reply().String(); err != nil || v != "alice" {
+		t.Errorf("HGet = %q, %v", v, err)
+	}
+	if v, err := score.Reply().Int64(); err != nil || v != 42 {
+		t.Errorf("ZScore = %d, %v", v, err)
+	}
+	if err := incr.Reply().Error(); err == nil {
+		t.Error("Incr should fail")
+	}
+	if !set.Reply().IsOK() {
+		t.Errorf("Set = %v", set.Reply().Error())
+	}
+
+	cmds = 0
+	abort := errors.New("abort")
+	if err := rp.Pipeline(func(p *Pipe) error {
+		set = p.Set("k", "v")
+		return abort
+	}); err != abort {
+		t.Errorf("Pipeline = %v, want abort", err)
+	}
+	if cmds != 0 || set.Reply().Error() != ErrNotExecuted {
+		t.Errorf("aborted pipeline sent %d commands", cmds)
+	}
+}
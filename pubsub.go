@@ -1,19 +1,34 @@
 package mredis
 
 import (
+	"context"
 	redigo "github.com/gomodule/redigo/redis"
 )
 
-func (rp *RedisPool) Publish(channel, value interface{}) error {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) Publish(channel, value interface{}) error {
+	return c.PublishCtx(context.Background(), channel, value)
+}
 
-	_, err := conn.Do("PUBLISH", channel, value)
+func (c cmdable) PublishCtx(ctx context.Context, channel, value interface{}) error {
+	_, err := c(ctx, "PUBLISH", channel, value)
 	return err
 }
 
 //must close manual
 func (rp *RedisPool) GetPubSubConn() (*redigo.PubSubConn, error) {
-	c:= rp.getConn()
-	return &redigo.PubSubConn{c}, nil
+	return rp.GetPubSubConnCtx(context.Background())
+}
+
+// ctx 只作用于从连接池获取连接, must close manual
+// Shutdown 时退订所有频道, Receive 收到退订消息后由调用方 Close
+func (rp *RedisPool) GetPubSubConnCtx(ctx context.Context) (*redigo.PubSubConn, error) {
+	c, err := rp.getConnCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.inflight.track(c.(*pooledConn)); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &redigo.PubSubConn{Conn: c}, nil
 }
@@ -0,0 +1,239 @@
+package mredis
+
+import (
+	"context"
+	"github.com/gomodule/redigo/redis"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 可以发往从库的只读命令
+var readOnlyCommands = map[string]bool{
+	"GET":              true,
+	"MGET":             true,
+	"EXISTS":           true,
+	"TTL":              true,
+	"KEYS":             true,
+	"HGET":             true,
+	"HMGET":            true,
+	"HGETALL":          true,
+	"HLEN":             true,
+	"LRANGE":           true,
+	"LLEN":             true,
+	"LINDEX":           true,
+	"SISMEMBER":        true,
+	"SMEMBERS":         true,
+	"SCARD":            true,
+	"SRANDMEMBER":      true,
+	"ZCOUNT":           true,
+	"ZCARD":            true,
+	"ZRANK":            true,
+	"ZREVRANK":         true,
+	"ZSCORE":           true,
+	"ZRANGE":           true,
+	"ZREVRANGE":        true,
+	"ZRANGEBYSCORE":    true,
+	"ZREVRANGEBYSCORE": true,
+	"GEODIST":          true,
+	"GEOPOS":           true,
+	"GEOHASH":          true,
+	"EVAL_RO":          true,
+	"EVALSHA_RO":       true,
+	"FCALL_RO":         true,
+}
+
+func isReadOnly(cmd string) bool {
+	return readOnlyCommands[strings.ToUpper(cmd)]
+}
+
+type Balance int
+
+const (
+	RoundRobin  Balance = iota // 轮询
+	LeastLoaded                // 选择使用中连接最少的从库
+)
+
+const ReplicaRetryDefault = 5 * time.Second
+
+type primaryKey struct{}
+
+// 返回的 ctx 用于 ReadWriteClient 时读命令也发往主库, 保证读到自己刚写入的数据
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func forcePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+type replica struct {
+	*RedisPool
+	downUntil int64 // UnixNano, 连接出错后在此之前不再使用
+}
+
+func (r *replica) healthy(now int64) bool {
+	return atomic.LoadInt64(&r.downUntil) <= now
+}
+
+func (r *replica) inUse() int {
+	s := r.current().Stats()
+	return s.ActiveCount - s.IdleCount
+}
+
+/*
+	ReadWriteClient 读写分离, 命令方法与 RedisPool 相同
+
+	只读命令发往从库, 其他命令发往主库; 从库连接出错时本次请求改用主库,
+	并在 retry 时间内不再使用该从库
+
+	对象命令使用 WithReadWriteCodec 指定的 codec, 没有指定时使用主库的 codec, 从库的 codec 不起作用
+*/
+type ReadWriteClient struct {
+	cmdable
+	objects
+	primary  *RedisPool
+	replicas []*replica
+	balance  Balance
+	retry    time.Duration
+	codec    Codec
+	next     uint32
+}
+
+type ReadWriteOption func(c *ReadWriteClient)
+
+func WithBalance(b Balance) ReadWriteOption {
+	return func(c *ReadWriteClient) { c.balance = b }
+}
+
+// 从库出错后暂停使用的时间
+func WithReplicaRetry(d time.Duration) ReadWriteOption {
+	return func(c *ReadWriteClient) { c.retry = d }
+}
+
+// 对象命令默认的 codec, 读写使用同一个, 不跟随主库
+func WithReadWriteCodec(codec Codec) ReadWriteOption {
+	return func(c *ReadWriteClient) { c.codec = codec }
+}
+
+/*
+	NewReadWriteClient("redis://:pwd@master:6379/0",
+		[]string{"redis://:pwd@replica1:6379/0", "redis://:pwd@replica2:6379/0"},
+		WithBalance(LeastLoaded))
+*/
+func NewReadWriteClient(primaryURL string, replicaURLs []string, opts ...ReadWriteOption) (*ReadWriteClient, error) {
+	primary, err := NewRedisPool(primaryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make([]*RedisPool, 0, len(replicaURLs))
+	for _, u := range replicaURLs {
+		r, err := NewRedisPool(u)
+		if err != nil {
+			primary.Close()
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, err
+		}
+		replicas = append(replicas, r)
+	}
+
+	return NewReadWriteClientWithPools(primary, replicas, opts...), nil
+}
+
+// 使用已经创建好的连接池, Close 时一起关闭
+func NewReadWriteClientWithPools(primary *RedisPool, replicas []*RedisPool, opts ...ReadWriteOption) *ReadWriteClient {
+	c := &ReadWriteClient{
+		primary:  primary,
+		replicas: make([]*replica, 0, len(replicas)),
+		retry:    ReplicaRetryDefault,
+	}
+	for _, r := range replicas {
+		c.replicas = append(c.replicas, &replica{RedisPool: r})
+	}
+	for _, o := range opts {
+		o(c)
+	}
+
+	codec := primary.codec
+	if c.codec != nil {
+		codec = staticCodec(c.codec)
+	}
+	c.cmdable = c.do
+	c.objects = objects{c: c.cmdable, codec: codec}
+	return c
+}
+
+func (c *ReadWriteClient) Primary() *RedisPool {
+	return c.primary
+}
+
+// 选择一个可用从库, 都不可用时返回 nil
+func (c *ReadWriteClient) pickReplica() *replica {
+	now := time.Now().UnixNano()
+	n := len(c.replicas)
+
+	switch c.balance {
+	case LeastLoaded:
+		var best *replica
+		for _, r := range c.replicas {
+			if r.healthy(now) && (best == nil || r.inUse() < best.inUse()) {
+				best = r
+			}
+		}
+		return best
+
+	default:
+		// 在 uint32 上取模, 32 位平台上转成 int 可能是负数
+		start := atomic.AddUint32(&c.next, 1)
+		for i := 0; i < n; i++ {
+			r := c.replicas[(start+uint32(i))%uint32(n)]
+			if r.healthy(now) {
+				return r
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *ReadWriteClient) do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	if !isReadOnly(cmd) || forcePrimary(ctx) {
+		return c.primary.do(ctx, cmd, args...)
+	}
+
+	r := c.pickReplica()
+	if r == nil {
+		return c.primary.do(ctx, cmd, args...)
+	}
+
+	value, err := r.do(ctx, cmd, args...)
+	if isConnError(err) && ctx.Err() == nil {
+		atomic.StoreInt64(&r.downUntil, time.Now().Add(c.retry).UnixNano())
+		return c.primary.do(ctx, cmd, args...)
+	}
+
+	return value, err
+}
+
+// 网络或连接池错误, 不包括 redis 返回的错误和空值
+func isConnError(err error) bool {
+	if err == nil || err == redis.ErrNil {
+		return false
+	}
+	_, ok := err.(redis.Error)
+	return !ok
+}
+
+func (c *ReadWriteClient) Close() error {
+	err := c.primary.Close()
+	for _, r := range c.replicas {
+		if e := r.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package mredis
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+// 返回自己的名字, 写命令记录到 writes
+func newFakeNamed(t *testing.T, name string, writes chan<- string) *fakeRedis {
+	return newFakeRedis(t, func(args []string) string {
+		if strings.ToUpper(args[0]) == "SET" {
+			writes <- name
+			return "+OK\r\n"
+		}
+		return bulk(name)
+	})
+}
+
+func TestReadWriteClient(t *testing.T) {
+	writes := make(chan string, 10)
+	primary := newFakeNamed(t, "primary", writes)
+	defer primary.Close()
+	replica := newFakeNamed(t, "replica", writes)
+
+	c, err := NewReadWriteClient("redis://"+primary.Addr().String(), []string{"redis://" + replica.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if v, _ := c.Get("k").String(); v != "replica" {
+		t.Errorf("read went to %q, want replica", v)
+	}
+	if v, _ := c.GetCtx(WithPrimary(context.Background()), "k").String(); v != "primary" {
+		t.Errorf("forced read went to %q, want primary", v)
+	}
+	if err := c.Set("k", "v"); err != nil || <-writes != "primary" {
+		t.Errorf("write did not go to primary: %v", err)
+	}
+
+	// 从库不可用时回退到主库
+	replica.Close()
+	c.replicas[0].current().Close()
+	if v, err := c.Get("k").String(); err != nil || v != "primary" {
+		t.Errorf("fallback read = %q, %v, want primary", v, err)
+	}
+	if c.pickReplica() != nil {
+		t.Error("failed replica should be marked down")
+	}
+}
+
+func TestPickReplicaWrap(t *testing.T) {
+	writes := make(chan string, 10)
+	primary := newFakeNamed(t, "primary", writes)
+	defer primary.Close()
+	r1 := newFakeNamed(t, "r1", writes)
+	defer r1.Close()
+	r2 := newFakeNamed(t, "r2", writes)
+	defer r2.Close()
+
+	c, err := NewReadWriteClient("redis://"+primary.Addr().String(),
+		[]string{"redis://" + r1.Addr().String(), "redis://" + r2.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// 计数器回绕时仍然轮流选择
+	c.next = math.MaxUint32 - 1
+	a, b := c.pickReplica(), c.pickReplica()
+	if a == nil || b == nil || a == b {
+		t.Errorf("picks around wrap = %p, %p, want two different replicas", a, b)
+	}
+}
@@ -0,0 +1,59 @@
+package mredis
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// 当前使用的 redis.Pool
+func (rp *RedisPool) current() *redis.Pool {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+
+	return rp.pool
+}
+
+/*
+	Reconfigure 按新的 URL(格式同 NewRedisPool) 替换连接地址和连接池参数
+
+	之后借出的连接使用新配置, 旧连接池不再借出连接, 空闲连接立即关闭,
+	正在使用的连接在命令执行完归还时关闭
+
+	新配置在原配置上合并 URL: 地址总是替换, URL 中没有写出的参数(包括 WithDialOptions, WithDialer
+	等 Option 的设置)保持不变, 规则见 applyURL; 健康检查和自动 pipeline 不受影响
+*/
+func (rp *RedisPool) Reconfigure(redisUrl string) error {
+	rp.mu.RLock()
+	opt := newPoolOption("")
+	if rp.opt != nil {
+		*opt = *rp.opt
+	}
+	rp.mu.RUnlock()
+
+	if err := opt.applyURL(redisUrl); err != nil {
+		return err
+	}
+
+	p, err := buildPool(opt)
+	if err != nil {
+		return err
+	}
+	rp.wrapDial(p)
+
+	rp.mu.Lock()
+	if rp.closed {
+		rp.mu.Unlock()
+		if opt.ownSentinel {
+			opt.Sentinel.Close()
+		}
+		p.Close()
+		return ErrPoolShutdown
+	}
+	old, oldSentinel, ownOld := rp.pool, rp.sentinel, rp.ownSentinel
+	rp.pool, rp.sentinel, rp.ownSentinel, rp.opt = p, opt.Sentinel, opt.ownSentinel, opt
+	rp.mu.Unlock()
+
+	if oldSentinel != nil && ownOld && oldSentinel != opt.Sentinel {
+		oldSentinel.Close()
+	}
+	return old.Close()
+}
@@ -0,0 +1,117 @@
+package mredis
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestReconfigure(t *testing.T) {
+	a := newFakeRedis(t, func(args []string) string {
+		if args[0] == "GET" && args[1] == "slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return bulk("a")
+	})
+	defer a.Close()
+	b := newFakeRedis(t, func(args []string) string {
+		return bulk("b")
+	})
+	defer b.Close()
+
+	rp, err := NewRedisPool("redis://" + a.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if v, _ := rp.Get("key").String(); v != "a" {
+		t.Fatalf("Get = %q, want a", v)
+	}
+
+	slow := make(chan string, 1)
+	go func() {
+		v, err := rp.Get("slow").String()
+		if err != nil {
+			v = err.Error()
+		}
+		slow <- v
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	if err := rp.Reconfigure("redis://" + b.Addr().String() + "?maxActive=3"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := rp.Get("key").String(); v != "b" {
+		t.Errorf("Get after Reconfigure = %q, want b", v)
+	}
+	if n := rp.current().MaxActive; n != 3 {
+		t.Errorf("MaxActive = %d, want 3", n)
+	}
+	if v := <-slow; v != "a" {
+		t.Errorf("in-flight Get = %q, want a", v)
+	}
+
+	if err := rp.Reconfigure("http://" + b.Addr().String()); err == nil {
+		t.Error("expected error for invalid url")
+	}
+	rp.Close()
+	if err := rp.Reconfigure("redis://" + b.Addr().String()); err != ErrPoolShutdown {
+		t.Errorf("Reconfigure after Close = %v, want ErrPoolShutdown", err)
+	}
+}
+
+// URL 中没有的参数和 Option 设置在 Reconfigure 之后保持不变
+func TestReconfigureKeepsOptions(t *testing.T) {
+	a := newFakeRedis(t, func(args []string) string { return bulk("a") })
+	defer a.Close()
+	b := newFakeRedis(t, func(args []string) string { return bulk("b") })
+	defer b.Close()
+
+	var dials int32
+	rp, err := NewRedisPoolWithOptions(a.Addr().String(),
+		WithMaxIdle(5),
+		WithDatabase(2),
+		WithDialOptions(redis.DialReadTimeout(time.Second)),
+		WithDialer(func(ctx context.Context, network, address string, options ...redis.DialOption) (redis.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return redis.DialContext(ctx, network, address, options...)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if err := rp.Reconfigure("redis://" + b.Addr().String() + "?maxActive=3"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := rp.Get("key").String(); v != "b" {
+		t.Errorf("Get after Reconfigure = %q, want b", v)
+	}
+	if p := rp.current(); p.MaxActive != 3 || p.MaxIdle != 5 {
+		t.Errorf("MaxActive = %d, MaxIdle = %d, want 3, 5", p.MaxActive, p.MaxIdle)
+	}
+	rp.mu.RLock()
+	opt := rp.opt
+	rp.mu.RUnlock()
+	if opt.Conn.DB != 2 || len(opt.Options) != 1 {
+		t.Errorf("DB = %d, Options = %d, want 2, 1", opt.Conn.DB, len(opt.Options))
+	}
+	if atomic.LoadInt32(&dials) == 0 {
+		t.Error("custom dialer not used after Reconfigure")
+	}
+
+	if err := rp.Reconfigure("redis://" + b.Addr().String() + "/0"); err != nil {
+		t.Fatal(err)
+	}
+	rp.mu.RLock()
+	db := rp.opt.Conn.DB
+	rp.mu.RUnlock()
+	if db != 0 {
+		t.Errorf("DB = %d, want 0 from url", db)
+	}
+}
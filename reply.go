@@ -1,6 +1,10 @@
 package mredis
 
-import "github.com/gomodule/redigo/redis"
+import (
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"math/big"
+)
 
 const (
 	SetNxFail = 0
@@ -11,6 +15,7 @@ type Reply struct {
 	Raw    interface{}  // source return data
 	Result interface{} //
 	Err    error
+	codec  Codec // Decode 使用, 对象命令返回的 Reply 才会设置
 }
 
 func reply(data interface{}, err error) *Reply {
@@ -67,8 +72,41 @@ func (r *Reply) String() (string, error) {
 	return redis.String(r.Raw, r.Err)
 }
 
+// RESP3 的 double 以文本返回, 同样可以转换
+func (r *Reply) Float64() (float64, error) {
+	return redis.Float64(r.Raw, r.Err)
+}
+
+func (r *Reply) Float64s() ([]float64, error) {
+	return redis.Float64s(r.pairs())
+}
+
+// RESP3 的 boolean 为 1/0
+func (r *Reply) Bool() (bool, error) {
+	return redis.Bool(r.Raw, r.Err)
+}
+
+// RESP3 的 big number
+func (r *Reply) BigInt() (*big.Int, error) {
+	s, err := redis.String(r.Raw, r.Err)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("mredis: invalid big number %q", s)
+	}
+	return n, nil
+}
+
+// 数组、RESP3 的 set 和 map(key, value 交替)
+func (r *Reply) Values() ([]interface{}, error) {
+	return redis.Values(r.Raw, r.Err)
+}
+
 func (r *Reply) Strings()([]string, error) {
-	return redis.Strings(r.Raw, r.Err)
+	return redis.Strings(r.pairs())
 }
 
 func (r *Reply) Bytes() ([]byte, error) {
@@ -76,19 +114,42 @@ func (r *Reply) Bytes() ([]byte, error) {
 }
 
 func (r *Reply) ByteSlices()([][]byte, error) {
-	return redis.ByteSlices(r.Raw, r.Err)
+	return redis.ByteSlices(r.pairs())
 }
 
 func (r *Reply) IntMap() (map[string]int, error) {
-	return redis.IntMap(r.Raw, r.Err)
+	return redis.IntMap(r.pairs())
 }
 
 func (r *Reply) Int64Map() (map[string]int64, error) {
-	return redis.Int64Map(r.Raw, r.Err)
+	return redis.Int64Map(r.pairs())
 }
 
 func (r *Reply) StringMap() (map[string]string, error) {
-	return redis.StringMap(r.Raw, r.Err)
+	return redis.StringMap(r.pairs())
+}
+
+/*
+	pairs 把 RESP3 返回的 [[member, score], ...] 展开为 [member, score, ...]:
+	RESP3 下 ZRANGE WITHSCORES 等命令按对返回, 和 RESP2 的结果一致后才能转换为 map 或 slice;
+	只有每个元素都是 2 个元素的数组时才展开, Values 返回原始结构
+*/
+func (r *Reply) pairs() (interface{}, error) {
+	values, ok := r.Raw.([]interface{})
+	if !ok || r.Err != nil || len(values) == 0 {
+		return r.Raw, r.Err
+	}
+
+	for _, v := range values {
+		if pair, ok := v.([]interface{}); !ok || len(pair) != 2 {
+			return r.Raw, r.Err
+		}
+	}
+	flat := make([]interface{}, 0, 2*len(values))
+	for _, v := range values {
+		flat = append(flat, v.([]interface{})...)
+	}
+	return flat, nil
 }
 
 func (r *Reply) CallFunc(f func(raw interface{}) error) error {
@@ -0,0 +1,642 @@
+package mredis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	ProtocolRESP2 = 2
+	ProtocolRESP3 = 3
+)
+
+var errConnClosed = errors.New("mredis: connection closed")
+
+/*
+	RESP3 连接, 通过 WithProtocol(ProtocolRESP3) 或者 URL 参数 protocol=3 开启
+
+	建立连接时发送 HELLO 3 [AUTH user password] [SETNAME name], 再 SELECT db;
+	连接参数通过 URL 或 WithAuth、WithDatabase、WithClientName、WithTLSConfig、WithNetDialer 设置,
+	同时使用 WithDialOptions 或 WithDialer 时返回错误
+	新增的类型转换成 redigo 已有的类型, Reply 原有的转换方法不受影响:
+		map          -> key, value 交替的 []interface{}, 同 RESP2 的 HGETALL
+		set          -> []interface{}
+		double       -> []byte 文本, Reply.Float64() 转换
+		big number   -> []byte 文本, Reply.BigInt() 转换
+		boolean      -> int64 1/0, Reply.Bool() 转换
+		verbatim     -> 去掉格式前缀(txt:)的 []byte
+		null         -> nil
+	push 消息中 pubsub 的消息(message, subscribe...)照常由 Receive 返回, redigo.PubSubConn 可以直接使用,
+	其他 push 消息(如 client tracking 的 invalidate)交给 WithPushHandler 设置的回调
+*/
+
+// 服务端主动推送的消息
+type PushMessage struct {
+	Kind string
+	Data []interface{}
+}
+
+// RESP3 push 消息的回调, 在读取回复的 goroutine 中调用, 不要阻塞
+func WithPushHandler(fn func(msg PushMessage)) Option {
+	return func(opt *poolOption) { opt.OnPush = fn }
+}
+
+// ProtocolRESP2(默认) 或 ProtocolRESP3
+func WithProtocol(protocol int) Option {
+	return func(opt *poolOption) { opt.Protocol = protocol }
+}
+
+// 用户名为空时使用 default 用户
+func WithAuth(username, password string) Option {
+	return func(opt *poolOption) {
+		opt.Conn.Username, opt.Conn.Password = username, password
+	}
+}
+
+func WithDatabase(db int) Option {
+	return func(opt *poolOption) { opt.Conn.DB = db }
+}
+
+func WithClientName(name string) Option {
+	return func(opt *poolOption) { opt.Conn.ClientName = name }
+}
+
+// 开启 TLS 并使用 c, ServerName 为空时使用连接地址的 host; RESP2 和 RESP3 连接都适用
+func WithTLSConfig(c *tls.Config) Option {
+	return func(opt *poolOption) {
+		opt.Conn.UseTLS = true
+		opt.Conn.TLSConfig = c
+	}
+}
+
+// 自定义建立 TCP 连接的方法(代理、特殊网络等), TLS 和握手仍由 mredis 完成; RESP2 和 RESP3 连接都适用
+func WithNetDialer(dial func(ctx context.Context, network, address string) (net.Conn, error)) Option {
+	return func(opt *poolOption) { opt.Conn.NetDial = dial }
+}
+
+// 连接参数, RESP2 连接通过 dialOptions 转成 redigo 的 DialOption, RESP3 连接自己完成握手
+type connConfig struct {
+	Username   string
+	Password   string
+	ClientName string
+	DB         int
+	UseTLS     bool
+	TLSConfig  *tls.Config
+	NetDial    func(ctx context.Context, network, address string) (net.Conn, error)
+
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+}
+
+func (c connConfig) dialOptions() []redis.DialOption {
+	options := append(c.netOptions(), redis.DialUseTLS(c.UseTLS))
+	if c.Username != "" {
+		options = append(options, redis.DialUsername(c.Username))
+	}
+	if c.Password != "" {
+		options = append(options, redis.DialPassword(c.Password))
+	}
+	if c.ClientName != "" {
+		options = append(options, redis.DialClientName(c.ClientName))
+	}
+	if c.DB != 0 {
+		options = append(options, redis.DialDatabase(c.DB))
+	}
+	if c.TLSConfig != nil {
+		options = append(options, redis.DialTLSConfig(c.TLSConfig))
+	}
+	if c.NetDial != nil {
+		options = append(options, redis.DialContextFunc(c.NetDial))
+	}
+	return options
+}
+
+// 网络超时, sentinel 连接也使用
+func (c connConfig) netOptions() []redis.DialOption {
+	options := make([]redis.DialOption, 0, 8)
+	if c.ConnectTimeout > 0 {
+		options = append(options, redis.DialConnectTimeout(c.ConnectTimeout))
+	}
+	if c.ReadTimeout > 0 {
+		options = append(options, redis.DialReadTimeout(c.ReadTimeout))
+	}
+	if c.WriteTimeout > 0 {
+		options = append(options, redis.DialWriteTimeout(c.WriteTimeout))
+	}
+	return options
+}
+
+var pubsubPushKinds = map[string]bool{
+	"message":      true,
+	"pmessage":     true,
+	"smessage":     true,
+	"subscribe":    true,
+	"psubscribe":   true,
+	"ssubscribe":   true,
+	"unsubscribe":  true,
+	"punsubscribe": true,
+	"sunsubscribe": true,
+}
+
+// push 帧, 读取时与普通数组区分
+type pushReply []interface{}
+
+// RESP3 连接只使用 conf 中的参数, 忽略 redigo 的 DialOption, 不能转换的配置在 buildPool 中报错
+func dialRESP3(conf connConfig, onPush func(msg PushMessage)) DialFunc {
+	return func(ctx context.Context, network, address string, _ ...redis.DialOption) (redis.Conn, error) {
+		netDial := conf.NetDial
+		if netDial == nil {
+			d := net.Dialer{Timeout: conf.ConnectTimeout, KeepAlive: 5 * time.Minute}
+			netDial = d.DialContext
+		}
+		nc, err := netDial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		if conf.UseTLS {
+			tc, err := tlsClient(ctx, nc, conf, address)
+			if err != nil {
+				nc.Close()
+				return nil, err
+			}
+			nc = tc
+		}
+
+		c := &resp3Conn{
+			nc:           nc,
+			br:           bufio.NewReader(nc),
+			bw:           bufio.NewWriter(nc),
+			readTimeout:  conf.ReadTimeout,
+			writeTimeout: conf.WriteTimeout,
+			onPush:       onPush,
+		}
+
+		args := []interface{}{ProtocolRESP3}
+		if conf.Password != "" {
+			username := conf.Username
+			if username == "" {
+				username = "default"
+			}
+			args = append(args, "AUTH", username, conf.Password)
+		}
+		if conf.ClientName != "" {
+			args = append(args, "SETNAME", conf.ClientName)
+		}
+		if _, err := c.DoContext(ctx, "HELLO", args...); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("mredis: HELLO 3: %v", err)
+		}
+
+		if conf.DB != 0 {
+			if _, err := c.DoContext(ctx, "SELECT", conf.DB); err != nil {
+				c.Close()
+				return nil, err
+			}
+		}
+
+		return c, nil
+	}
+}
+
+// 同 redigo: 复制 conf.TLSConfig, ServerName 默认为 address 的 host; 握手受 ctx 的截止时间和 ConnectTimeout 限制
+func tlsClient(ctx context.Context, nc net.Conn, conf connConfig, address string) (*tls.Conn, error) {
+	c := &tls.Config{}
+	if conf.TLSConfig != nil {
+		c = conf.TLSConfig.Clone()
+	}
+	if c.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+		c.ServerName = host
+	}
+
+	var deadline time.Time
+	if conf.ConnectTimeout > 0 {
+		deadline = time.Now().Add(conf.ConnectTimeout)
+	}
+	if dl, ok := ctx.Deadline(); ok && (deadline.IsZero() || dl.Before(deadline)) {
+		deadline = dl
+	}
+	if err := nc.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	tc := tls.Client(nc, c)
+	if err := tc.Handshake(); err != nil {
+		return nil, err
+	}
+	return tc, nc.SetDeadline(time.Time{})
+}
+
+// 实现 redis.Conn, redis.ConnWithContext 和 redis.ConnWithTimeout
+type resp3Conn struct {
+	nc           net.Conn
+	br           *bufio.Reader
+	bw           *bufio.Writer
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	onPush       func(msg PushMessage)
+
+	mu      sync.Mutex
+	pending int
+	err     error
+}
+
+func (c *resp3Conn) fatal(err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err == nil {
+		c.err = err
+		c.nc.Close()
+	}
+	return err
+}
+
+func (c *resp3Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+func (c *resp3Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.err
+	if c.err == nil {
+		c.err = errConnClosed
+		err = c.nc.Close()
+	}
+	return err
+}
+
+func (c *resp3Conn) Send(cmd string, args ...interface{}) error {
+	c.mu.Lock()
+	c.pending++
+	c.mu.Unlock()
+
+	if err := c.writeCommand(cmd, args); err != nil {
+		return c.fatal(err)
+	}
+	return nil
+}
+
+func (c *resp3Conn) Flush() error {
+	if c.writeTimeout != 0 {
+		c.nc.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	if err := c.bw.Flush(); err != nil {
+		return c.fatal(err)
+	}
+	return nil
+}
+
+func (c *resp3Conn) Receive() (interface{}, error) {
+	return c.ReceiveWithTimeout(c.readTimeout)
+}
+
+func (c *resp3Conn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	c.setReadDeadline(timeout)
+
+	reply, err := c.readReply(true)
+	if err != nil {
+		return nil, c.fatal(err)
+	}
+
+	c.mu.Lock()
+	if c.pending > 0 {
+		c.pending--
+	}
+	c.mu.Unlock()
+
+	if e, ok := reply.(redis.Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+func (c *resp3Conn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	stop := c.watch(ctx)
+	reply, err := c.Receive()
+	return c.contextResult(ctx, stop, reply, err)
+}
+
+func (c *resp3Conn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	return c.DoWithTimeout(c.readTimeout, cmd, args...)
+}
+
+func (c *resp3Conn) DoContext(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	stop := c.watch(ctx)
+	reply, err := c.Do(cmd, args...)
+	return c.contextResult(ctx, stop, reply, err)
+}
+
+// 同 redigo: 先读取之前 Send 的回复, cmd 为空时返回所有回复, 否则返回最后一条
+func (c *resp3Conn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = 0
+	c.mu.Unlock()
+
+	if cmd == "" && pending == 0 {
+		return nil, nil
+	}
+
+	if cmd != "" {
+		if err := c.writeCommand(cmd, args); err != nil {
+			return nil, c.fatal(err)
+		}
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+
+	c.setReadDeadline(timeout)
+
+	if cmd == "" {
+		replies := make([]interface{}, pending)
+		for i := range replies {
+			r, err := c.readReply(false)
+			if err != nil {
+				return nil, c.fatal(err)
+			}
+			replies[i] = r
+		}
+		return replies, nil
+	}
+
+	var err error
+	var reply interface{}
+	for i := 0; i <= pending; i++ {
+		var e error
+		if reply, e = c.readReply(false); e != nil {
+			return nil, c.fatal(e)
+		}
+		if e, ok := reply.(redis.Error); ok && err == nil {
+			err = e
+		}
+	}
+	return reply, err
+}
+
+func (c *resp3Conn) setReadDeadline(timeout time.Duration) {
+	var deadline time.Time
+	if timeout != 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	c.nc.SetReadDeadline(deadline)
+}
+
+// ctx 结束时把读写期限设为过去, 正在进行的读写立即返回; 返回的 stop 结束监视
+func (c *resp3Conn) watch(ctx context.Context) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.nc.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// ctx 结束后连接的读写期限可能已经被修改, 不能再使用
+func (c *resp3Conn) contextResult(ctx context.Context, stop func(), reply interface{}, err error) (interface{}, error) {
+	stop()
+	if ctx.Err() != nil {
+		c.fatal(ctx.Err())
+		if err != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return reply, err
+}
+
+// pubsub 为 true 时 pubsub 的 push 消息作为回复返回, 其他 push 消息交给回调
+func (c *resp3Conn) readReply(pubsub bool) (interface{}, error) {
+	for {
+		reply, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		push, ok := reply.(pushReply)
+		if !ok {
+			return reply, nil
+		}
+
+		msg := PushMessage{Data: push}
+		if len(push) > 0 {
+			msg.Kind, _ = redis.String(push[0], nil)
+		}
+		if pubsub && pubsubPushKinds[msg.Kind] {
+			return []interface{}(push), nil
+		}
+		if c.onPush != nil {
+			c.onPush(msg)
+		}
+	}
+}
+
+type protocolError string
+
+func (pe protocolError) Error() string {
+	return fmt.Sprintf("mredis: %s (possible server error or unsupported concurrent read by application)", string(pe))
+}
+
+func (c *resp3Conn) readLine() ([]byte, error) {
+	p, err := c.br.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return nil, protocolError("long response line")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	i := len(p) - 2
+	if i < 1 || p[i] != '\r' {
+		return nil, protocolError("bad response line terminator")
+	}
+	return p[:i], nil
+}
+
+func (c *resp3Conn) readFrame() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return redis.Error(line[1:]), nil
+	case ':':
+		return parseFrameInt(line[1:])
+	case '_':
+		return nil, nil
+	case '#':
+		if string(line[1:]) == "t" {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case ',', '(':
+		return append([]byte(nil), line[1:]...), nil
+	case '$', '=', '!':
+		n, err := parseFrameLen(line[1:])
+		if n < 0 || err != nil {
+			return nil, err
+		}
+		p := make([]byte, n+2)
+		if _, err := readFull(c.br, p); err != nil {
+			return nil, err
+		}
+		p = p[:n]
+		switch line[0] {
+		case '=':
+			// 格式前缀, 如 txt:
+			if len(p) >= 4 && p[3] == ':' {
+				p = p[4:]
+			}
+		case '!':
+			return redis.Error(p), nil
+		}
+		return p, nil
+	case '*', '~', '>', '%':
+		n, err := parseFrameLen(line[1:])
+		if n < 0 || err != nil {
+			return nil, err
+		}
+		if line[0] == '%' {
+			n *= 2
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = c.readFrame(); err != nil {
+				return nil, err
+			}
+		}
+		if line[0] == '>' {
+			return pushReply(values), nil
+		}
+		return values, nil
+	case '|':
+		// attribute, 忽略后读取真正的回复
+		n, err := parseFrameLen(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < 2*n; i++ {
+			if _, err := c.readFrame(); err != nil {
+				return nil, err
+			}
+		}
+		return c.readFrame()
+	}
+
+	return nil, protocolError("unexpected response line " + strconv.Quote(string(line)))
+}
+
+func readFull(r *bufio.Reader, p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		m, err := r.Read(p[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func parseFrameLen(p []byte) (int, error) {
+	if len(p) == 0 || p[0] == '?' {
+		return -1, protocolError("streamed replies are not supported")
+	}
+	n, err := strconv.Atoi(string(p))
+	if err != nil {
+		return -1, protocolError("bad length " + strconv.Quote(string(p)))
+	}
+	return n, nil
+}
+
+func parseFrameInt(p []byte) (interface{}, error) {
+	n, err := strconv.ParseInt(string(p), 10, 64)
+	if err != nil {
+		return nil, protocolError("bad integer " + strconv.Quote(string(p)))
+	}
+	return n, nil
+}
+
+func (c *resp3Conn) writeCommand(cmd string, args []interface{}) error {
+	c.writeLen('*', 1+len(args))
+	c.writeString(cmd)
+	for _, arg := range args {
+		c.writeArg(arg)
+	}
+	return nil
+}
+
+func (c *resp3Conn) writeLen(prefix byte, n int) {
+	c.bw.WriteByte(prefix)
+	c.bw.WriteString(strconv.Itoa(n))
+	c.bw.WriteString("\r\n")
+}
+
+func (c *resp3Conn) writeString(s string) {
+	c.writeLen('$', len(s))
+	c.bw.WriteString(s)
+	c.bw.WriteString("\r\n")
+}
+
+func (c *resp3Conn) writeBytes(p []byte) {
+	c.writeLen('$', len(p))
+	c.bw.Write(p)
+	c.bw.WriteString("\r\n")
+}
+
+// 参数转换规则同 redigo
+func (c *resp3Conn) writeArg(arg interface{}) {
+	switch a := arg.(type) {
+	case string:
+		c.writeString(a)
+	case []byte:
+		c.writeBytes(a)
+	case int:
+		c.writeString(strconv.Itoa(a))
+	case int64:
+		c.writeString(strconv.FormatInt(a, 10))
+	case float64:
+		c.writeString(strconv.FormatFloat(a, 'g', -1, 64))
+	case bool:
+		if a {
+			c.writeString("1")
+		} else {
+			c.writeString("0")
+		}
+	case nil:
+		c.writeString("")
+	case redis.Argument:
+		c.writeArg(a.RedisArg())
+	default:
+		c.writeString(fmt.Sprint(arg))
+	}
+}
@@ -0,0 +1,218 @@
+package mredis
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestRESP3(t *testing.T) {
+	var mu sync.Mutex
+	var hello, selected []string
+	srv := newFakeRedis(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		push := ">2\r\n" + bulk("invalidate") + array(bulk("user:1"))
+		switch args[0] {
+		case "HELLO":
+			hello = args
+			return "%2\r\n" + bulk("server") + bulk("redis") + bulk("proto") + ":3\r\n"
+		case "SELECT":
+			selected = args
+			return "+OK\r\n"
+		case "HGETALL":
+			return "%2\r\n" + bulk("a") + bulk("1") + bulk("b") + bulk("2")
+		case "SMEMBERS":
+			return "~2\r\n:1\r\n:2\r\n"
+		case "ZSCORE":
+			return push + ",3.5\r\n"
+		case "GET":
+			return "(3492890328409238509324850943850943825024385\r\n"
+		case "INFO":
+			return "=15\r\ntxt:Some string\r\n"
+		case "EXISTS":
+			return "|1\r\n" + bulk("ttl") + ":3600\r\n" + "#t\r\n"
+		case "TYPE":
+			return "_\r\n"
+		case "SUBSCRIBE":
+			return push + ">3\r\n" + bulk("subscribe") + bulk(args[1]) + ":1\r\n" +
+				">3\r\n" + bulk("message") + bulk(args[1]) + bulk("hello")
+		case "UNSUBSCRIBE", "PUNSUBSCRIBE":
+			return ">3\r\n" + bulk(strings.ToLower(args[0])) + "_\r\n:0\r\n"
+		case "ECHO":
+			return bulk(args[1])
+		case "ZRANGE":
+			return "*2\r\n" + "*2\r\n" + bulk("a") + ",1.5\r\n" + "*2\r\n" + bulk("b") + ",2\r\n"
+		}
+		return "+OK\r\n"
+	})
+	defer srv.Close()
+
+	pushes := make(chan PushMessage, 8)
+	opt, err := parsePoolOption("redis://app:secret@" + srv.Addr().String() + "/2?protocol=3&clientName=worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp, err := NewRedisPoolWithOptions("", withPoolOption(opt), WithPushHandler(func(msg PushMessage) {
+		pushes <- msg
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if m, err := rp.Do("HGETALL", "h").StringMap(); err != nil || m["a"] != "1" || m["b"] != "2" {
+		t.Errorf("HGETALL = %v, %v", m, err)
+	}
+	mu.Lock()
+	if strings.Join(hello, " ") != "HELLO 3 AUTH app secret SETNAME worker" {
+		t.Errorf("HELLO args = %v", hello)
+	}
+	if strings.Join(selected, " ") != "SELECT 2" {
+		t.Errorf("SELECT args = %v", selected)
+	}
+	mu.Unlock()
+
+	if v, err := rp.Do("SMEMBERS", "s").Int64s(); err != nil || len(v) != 2 || v[0] != 1 || v[1] != 2 {
+		t.Errorf("SMEMBERS = %v, %v", v, err)
+	}
+	if f, err := rp.Do("ZSCORE", "z", "m").Float64(); err != nil || f != 3.5 {
+		t.Errorf("ZSCORE = %v, %v", f, err)
+	}
+	select {
+	case msg := <-pushes:
+		if msg.Kind != "invalidate" || len(msg.Data) != 2 {
+			t.Errorf("push = %+v", msg)
+		}
+	default:
+		t.Error("push handler was not called")
+	}
+	if n, err := rp.Do("GET", "big").BigInt(); err != nil || n.String() != "3492890328409238509324850943850943825024385" {
+		t.Errorf("GET = %v, %v", n, err)
+	}
+	if s, err := rp.Do("INFO").String(); err != nil || s != "Some string" {
+		t.Errorf("INFO = %q, %v", s, err)
+	}
+	if ok, err := rp.Do("EXISTS", "k").Bool(); err != nil || !ok {
+		t.Errorf("EXISTS = %v, %v", ok, err)
+	}
+	if r := rp.Do("TYPE", "k"); r.Err != nil || r.Raw != nil {
+		t.Errorf("TYPE = %v, %v", r.Raw, r.Err)
+	}
+
+	// RESP3 的 WITHSCORES 按 [member, score] 返回
+	if m, err := rp.ZRangeWithScore("z", 0, -1).StringMap(); err != nil || m["a"] != "1.5" || m["b"] != "2" {
+		t.Errorf("ZRANGE WITHSCORES StringMap = %v, %v", m, err)
+	}
+	if s, err := rp.ZRangeWithScore("z", 0, -1).Strings(); err != nil || strings.Join(s, " ") != "a 1.5 b 2" {
+		t.Errorf("ZRANGE WITHSCORES Strings = %v, %v", s, err)
+	}
+
+	psc, err := rp.GetPubSubConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer psc.Close()
+	if err := psc.Subscribe("news"); err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := psc.Receive().(redis.Subscription); !ok || s.Kind != "subscribe" || s.Channel != "news" {
+		t.Errorf("subscription = %v", s)
+	}
+	if m, ok := psc.Receive().(redis.Message); !ok || m.Channel != "news" || string(m.Data) != "hello" {
+		t.Errorf("message = %v", m)
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	opt, err := parsePoolOption("rediss://localhost:6380?protocol=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.Protocol != ProtocolRESP3 || !opt.Conn.UseTLS {
+		t.Errorf("Protocol = %d, UseTLS = %v", opt.Protocol, opt.Conn.UseTLS)
+	}
+
+	if _, err := parsePoolOption("redis://localhost?protocol=4"); err == nil {
+		t.Error("protocol=4 accepted")
+	}
+}
+
+func TestRESP3TLS(t *testing.T) {
+	// 借用 httptest 自带的自签名证书
+	hs := httptest.NewTLSServer(nil)
+	cert := hs.TLS.Certificates
+	hs.Close()
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveFake(c, func(args []string) string {
+				if args[0] == "HELLO" {
+					return "%1\r\n" + bulk("proto") + ":3\r\n"
+				}
+				return bulk(args[0])
+			})
+		}
+	}()
+
+	var dials int32
+	rp, err := NewRedisPoolWithOptions(l.Addr().String(),
+		WithProtocol(ProtocolRESP3),
+		WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+		WithNetDialer(func(ctx context.Context, network, address string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if v, err := rp.Get("k").String(); err != nil || v != "GET" {
+		t.Errorf("Get over TLS = %q, %v", v, err)
+	}
+	if atomic.LoadInt32(&dials) != 1 {
+		t.Errorf("dials = %d, want 1", dials)
+	}
+
+	// 证书校验失败
+	strict, err := NewRedisPoolWithOptions(l.Addr().String(), WithProtocol(ProtocolRESP3), WithTLSConfig(&tls.Config{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer strict.Close()
+	if err := strict.Get("k").Error(); err == nil {
+		t.Error("self-signed certificate accepted")
+	}
+}
+
+func TestRESP3UnsupportedOptions(t *testing.T) {
+	if _, err := NewRedisPoolWithOptions("127.0.0.1:6379", WithProtocol(ProtocolRESP3), WithDialOptions(redis.DialPassword("x"))); err == nil {
+		t.Error("WithDialOptions accepted with RESP3")
+	}
+	dial := func(ctx context.Context, network, address string, options ...redis.DialOption) (redis.Conn, error) {
+		return redis.DialContext(ctx, network, address, options...)
+	}
+	if _, err := NewRedisPoolWithOptions("127.0.0.1:6379", WithProtocol(ProtocolRESP3), WithDialer(dial)); err == nil {
+		t.Error("WithDialer accepted with RESP3")
+	}
+}
@@ -0,0 +1,141 @@
+package mredis
+
+import (
+	"context"
+	"github.com/gomodule/redigo/redis"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+	Script lua 脚本, 通过 EVALSHA 执行, 服务端没有缓存时(NOSCRIPT)自动改用 EVAL
+
+	var incrCapped = mredis.NewScript(1, `
+		local v = redis.call('INCR', KEYS[1])
+		if v > tonumber(ARGV[1]) then redis.call('SET', KEYS[1], ARGV[1]) return tonumber(ARGV[1]) end
+		return v`)
+
+	rp.RegisterScript(incrCapped)
+	n, err := rp.RunScript(incrCapped, "counter", 100).Int64()
+*/
+type Script struct {
+	*redis.Script
+	keyCount int
+	src      string
+}
+
+// keyCount < 0 时 keysAndArgs 的第一个参数为 key 的个数, 同 redis.NewScript
+func NewScript(keyCount int, src string) *Script {
+	return &Script{Script: redis.NewScript(keyCount, src), keyCount: keyCount, src: src}
+}
+
+func (s *Script) args(spec string, keysAndArgs []interface{}) []interface{} {
+	if s.keyCount < 0 {
+		args := make([]interface{}, 1+len(keysAndArgs))
+		args[0] = spec
+		copy(args[1:], keysAndArgs)
+		return args
+	}
+
+	args := make([]interface{}, 2+len(keysAndArgs))
+	args[0] = spec
+	args[1] = s.keyCount
+	copy(args[2:], keysAndArgs)
+	return args
+}
+
+func (c cmdable) RunScript(s *Script, keysAndArgs ...interface{}) *Reply {
+	return c.RunScriptCtx(context.Background(), s, keysAndArgs...)
+}
+
+func (c cmdable) RunScriptCtx(ctx context.Context, s *Script, keysAndArgs ...interface{}) *Reply {
+	value, err := c(ctx, "EVALSHA", s.args(s.Hash(), keysAndArgs)...)
+	if isNoScript(err) {
+		value, err = c(ctx, "EVAL", s.args(s.src, keysAndArgs)...)
+	}
+	return reply(value, err)
+}
+
+func isNoScript(err error) bool {
+	e, ok := err.(redis.Error)
+	return ok && strings.HasPrefix(string(e), "NOSCRIPT ")
+}
+
+var scriptCommands = map[string]bool{
+	"EVAL":       true,
+	"EVALSHA":    true,
+	"EVAL_RO":    true,
+	"EVALSHA_RO": true,
+	"FCALL":      true,
+	"FCALL_RO":   true,
+}
+
+func isScriptCommand(cmd string) bool {
+	return scriptCommands[strings.ToUpper(cmd)]
+}
+
+// EVAL/EVALSHA/FCALL 的第一个 key: script numkeys key [key ...] arg [arg ...]
+func scriptKey(args []interface{}) (string, bool) {
+	if len(args) < 3 {
+		return "", false
+	}
+
+	n, err := strconv.Atoi(keyString(args[1]))
+	if err != nil || n <= 0 {
+		return "", false
+	}
+	return keyString(args[2]), true
+}
+
+/*
+	RegisterScript 注册脚本并立即 SCRIPT LOAD, 之后新建的连接(包括断线重连)都会先加载已注册的脚本,
+	服务端重启清空脚本缓存后 EVALSHA 仍然可以直接命中
+*/
+func (rp *RedisPool) RegisterScript(scripts ...*Script) error {
+	return rp.RegisterScriptCtx(context.Background(), scripts...)
+}
+
+func (rp *RedisPool) RegisterScriptCtx(ctx context.Context, scripts ...*Script) error {
+	rp.scripts.add(scripts...)
+
+	for _, s := range scripts {
+		if _, err := rp.do(ctx, "SCRIPT", "LOAD", s.src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scriptRegistry struct {
+	mu      sync.RWMutex
+	scripts []*Script
+}
+
+func (r *scriptRegistry) add(scripts ...*Script) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range scripts {
+		dup := false
+		for _, old := range r.scripts {
+			if old.Hash() == s.Hash() {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			r.scripts = append(r.scripts, s)
+		}
+	}
+}
+
+// 加载失败时忽略, 执行时还会 EVAL
+func (r *scriptRegistry) preload(c redis.Conn) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.scripts {
+		s.Load(c)
+	}
+}
@@ -0,0 +1,72 @@
+package mredis
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sync"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	var mu sync.Mutex
+	cache := make(map[string]bool)
+	evals := 0
+	srv := newFakeRedis(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch args[0] {
+		case "SCRIPT":
+			sum := sha1.Sum([]byte(args[2]))
+			sha := hex.EncodeToString(sum[:])
+			cache[sha] = true
+			return bulk(sha)
+		case "EVALSHA":
+			if !cache[args[1]] {
+				return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+			}
+			return ":1\r\n"
+		case "EVAL":
+			evals++
+			return ":2\r\n"
+		}
+		return "+OK\r\n"
+	})
+	defer srv.Close()
+
+	// 不保留空闲连接, 每条命令都新建连接, 模拟断线重连
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(), WithMaxIdle(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	s := NewScript(1, "return redis.call('GET', KEYS[1])")
+	if n, err := rp.RunScript(s, "k").Int(); err != nil || n != 2 || evals != 1 {
+		t.Fatalf("RunScript before load = %d, %v, evals %d", n, err, evals)
+	}
+
+	if err := rp.RegisterScript(s); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := rp.RunScript(s, "k").Int(); err != nil || n != 1 {
+		t.Errorf("RunScript after load = %d, %v", n, err)
+	}
+
+	// 服务端重启清空了脚本缓存, 新连接会重新加载
+	mu.Lock()
+	cache = make(map[string]bool)
+	mu.Unlock()
+	if n, err := rp.RunScript(s, "k").Int(); err != nil || n != 1 || evals != 1 {
+		t.Errorf("RunScript after reconnect = %d, %v, evals %d", n, err, evals)
+	}
+}
+
+func TestScriptKey(t *testing.T) {
+	if key, ok := commandKey("EVALSHA", []interface{}{"sha", 2, "a", "b", "arg"}); !ok || key != "a" {
+		t.Errorf("commandKey(EVALSHA) = %q, %v", key, ok)
+	}
+	if _, ok := commandKey("EVAL", []interface{}{"src", 0, "arg"}); ok {
+		t.Error("EVAL without keys should be keyless")
+	}
+}
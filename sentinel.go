@@ -0,0 +1,355 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sentinelScheme      = "redis+sentinel"
+	sentinelPortDefault = "26379"
+	switchMasterChannel = "+switch-master"
+
+	sentinelTimeout = 3 * time.Second // watch 过程中查询 sentinel 的超时
+	sentinelRetry   = time.Second     // 订阅断开后重试间隔
+)
+
+var (
+	ErrNoSentinel     = errors.New("mredis: no sentinel available")
+	ErrMasterChanged  = errors.New("mredis: master changed")
+	ErrNotMaster      = errors.New("mredis: connection is not a master")
+	errSentinelClosed = errors.New("mredis: sentinel closed")
+)
+
+/*
+	Sentinel 通过 SENTINEL get-master-addr-by-name 获取 master 地址,
+	订阅 +switch-master 跟随主从切换, 切换后连接池中的旧连接在借出时被丢弃
+*/
+type Sentinel struct {
+	MasterName string
+	Options    []redis.DialOption // 连接 sentinel 使用
+
+	mu       sync.Mutex
+	addrs    []string // 上次可用的 sentinel 排在最前
+	master   string
+	psc      redis.Conn
+	closed   bool
+	done     chan struct{}
+	watching sync.Once
+}
+
+func NewSentinel(addrs []string, masterName string, options ...redis.DialOption) *Sentinel {
+	return &Sentinel{
+		MasterName: masterName,
+		Options:    options,
+		addrs:      append([]string(nil), addrs...),
+		done:       make(chan struct{}),
+	}
+}
+
+// 连接池使用 sentinel 发现的 master, addr 参数被忽略; s 可以被多个连接池共用, 连接池关闭时不会关闭 s
+func WithSentinel(s *Sentinel) Option {
+	return func(opt *poolOption) { opt.Sentinel = s }
+}
+
+// sentinel url: host 为逗号分隔的地址列表, path 为 /master[/db], 返回剩下的 /db 部分
+func parseSentinel(u *url.URL, netOptions []redis.DialOption) (*Sentinel, string, error) {
+	addrs := make([]string, 0, 3)
+	for _, hostport := range strings.Split(u.Host, ",") {
+		if hostport == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(hostport); err != nil {
+			hostport = net.JoinHostPort(hostport, sentinelPortDefault)
+		}
+		addrs = append(addrs, hostport)
+	}
+	if len(addrs) == 0 {
+		return nil, "", fmt.Errorf("invalid sentinel address: %s", u.Host)
+	}
+
+	path := strings.TrimPrefix(u.Path, "/")
+	name, rest := path, ""
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		name, rest = path[:i], path[i:]
+	}
+	if name == "" {
+		return nil, "", fmt.Errorf("invalid sentinel master name: %s", u.Path)
+	}
+
+	options := append([]redis.DialOption(nil), netOptions...)
+	if password := u.Query().Get("sentinelPassword"); password != "" {
+		options = append(options, redis.DialPassword(password))
+	}
+
+	return NewSentinel(addrs, name, options...), rest, nil
+}
+
+func (s *Sentinel) Addrs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.addrs...)
+}
+
+// 返回当前 master 地址, 没有缓存时向 sentinel 查询
+func (s *Sentinel) MasterAddr(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	master := s.master
+	s.mu.Unlock()
+
+	if master != "" {
+		return master, nil
+	}
+
+	return s.discover(ctx)
+}
+
+// 依次询问 sentinel, 成功的 sentinel 调整到最前
+func (s *Sentinel) discover(ctx context.Context) (string, error) {
+	lastErr := ErrNoSentinel
+	for _, addr := range s.Addrs() {
+		master, err := s.queryMaster(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		s.mu.Lock()
+		s.master = master
+		for i, a := range s.addrs {
+			if a == addr {
+				copy(s.addrs[1:i+1], s.addrs[:i])
+				s.addrs[0] = addr
+				break
+			}
+		}
+		s.mu.Unlock()
+
+		return master, nil
+	}
+
+	return "", lastErr
+}
+
+func (s *Sentinel) queryMaster(ctx context.Context, addr string) (string, error) {
+	c, err := redis.DialContext(ctx, "tcp", addr, s.Options...)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	res, err := redis.Strings(doContext(c, ctx, "SENTINEL", "get-master-addr-by-name", s.MasterName))
+	if err == redis.ErrNil {
+		return "", fmt.Errorf("mredis: sentinel %s does not know master %s", addr, s.MasterName)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(res) != 2 {
+		return "", fmt.Errorf("mredis: invalid get-master-addr-by-name reply %v", res)
+	}
+
+	return net.JoinHostPort(res[0], res[1]), nil
+}
+
+func (s *Sentinel) setMaster(addr string) {
+	s.mu.Lock()
+	s.master = addr
+	s.mu.Unlock()
+}
+
+// master 连不上或者角色不对时清掉缓存, 下次拨号重新查询
+func (s *Sentinel) invalidate(addr string) {
+	s.mu.Lock()
+	if s.master == addr {
+		s.master = ""
+	}
+	s.mu.Unlock()
+}
+
+func (s *Sentinel) currentMaster() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.master
+}
+
+// 拨号到当前 master 并确认角色
+func (s *Sentinel) dial(ctx context.Context, dial DialFunc, network string, options ...redis.DialOption) (redis.Conn, error) {
+	addr, err := s.MasterAddr(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := dial(ctx, network, addr, options...)
+	if err != nil {
+		s.invalidate(addr)
+		return nil, err
+	}
+
+	if err := checkRole(c); err != nil {
+		c.Close()
+		s.invalidate(addr)
+		return nil, err
+	}
+
+	return &sentinelConn{wrappedConn: wrappedConn{c}, addr: addr}, nil
+}
+
+// master 已经切换的连接直接丢弃, 空闲超过 healthCheck 的连接检查 ROLE
+func (s *Sentinel) testOnBorrow(healthCheck time.Duration) func(c redis.Conn, t time.Time) error {
+	return func(c redis.Conn, t time.Time) error {
+		sc, ok := c.(*sentinelConn)
+		if ok && sc.addr != s.currentMaster() {
+			return ErrMasterChanged
+		}
+
+		if healthCheck <= 0 || time.Since(t) < healthCheck {
+			return nil
+		}
+
+		err := checkRole(c)
+		if err != nil && ok {
+			s.invalidate(sc.addr)
+		}
+		return err
+	}
+}
+
+func checkRole(c redis.Conn) error {
+	values, err := redis.Values(c.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return ErrNotMaster
+	}
+
+	role, err := redis.String(values[0], nil)
+	if err != nil {
+		return err
+	}
+	if role != "master" {
+		return ErrNotMaster
+	}
+
+	return nil
+}
+
+// 启动 +switch-master 订阅, 多个连接池共用一个 Sentinel 时只启动一次
+func (s *Sentinel) start() {
+	s.watching.Do(func() {
+		go s.watch()
+	})
+}
+
+func (s *Sentinel) watch() {
+	for {
+		err := s.subscribe()
+		if err == errSentinelClosed {
+			return
+		}
+
+		select {
+		case <-s.done:
+			return
+		case <-time.After(sentinelRetry):
+		}
+	}
+}
+
+// 订阅任意一个可用的 sentinel, 直到连接断开
+func (s *Sentinel) subscribe() error {
+	lastErr := ErrNoSentinel
+	for _, addr := range s.Addrs() {
+		c, err := redis.Dial("tcp", addr, s.Options...)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			c.Close()
+			return errSentinelClosed
+		}
+		s.psc = c
+		s.mu.Unlock()
+
+		return s.receive(redis.PubSubConn{Conn: c})
+	}
+
+	return lastErr
+}
+
+func (s *Sentinel) receive(psc redis.PubSubConn) error {
+	defer psc.Close()
+
+	if err := psc.Subscribe(switchMasterChannel); err != nil {
+		return err
+	}
+
+	// 订阅之后重新查询一次, 断线期间发生的切换不会丢失
+	ctx, cancel := context.WithTimeout(context.Background(), sentinelTimeout)
+	s.discover(ctx)
+	cancel()
+
+	for {
+		switch v := psc.ReceiveWithTimeout(0).(type) {
+		case redis.Message:
+			s.handleSwitch(string(v.Data))
+
+		case error:
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+
+			if closed {
+				return errSentinelClosed
+			}
+			return v
+		}
+	}
+}
+
+// <master name> <old ip> <old port> <new ip> <new port>
+func (s *Sentinel) handleSwitch(msg string) {
+	fields := strings.Fields(msg)
+	if len(fields) != 5 || fields[0] != s.MasterName {
+		return
+	}
+
+	s.setMaster(net.JoinHostPort(fields[3], fields[4]))
+}
+
+// 停止订阅, 不影响已经创建的连接
+func (s *Sentinel) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+
+	if s.psc != nil {
+		return s.psc.Close()
+	}
+	return nil
+}
+
+// 记录连接对应的 master 地址, 借出时判断 master 是否已经切换
+type sentinelConn struct {
+	wrappedConn
+	addr string
+}
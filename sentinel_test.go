@@ -0,0 +1,131 @@
+package mredis
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// master 返回自己的名字, ROLE 返回 master
+func newFakeMaster(t *testing.T, name string) *fakeRedis {
+	return newFakeRedis(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "ROLE":
+			return array(bulk("master"), ":0\r\n", "*0\r\n")
+		case "GET":
+			return bulk(name)
+		}
+		return "+OK\r\n"
+	})
+}
+
+func TestSentinelSwitchMaster(t *testing.T) {
+	a := newFakeMaster(t, "A")
+	defer a.Close()
+	b := newFakeMaster(t, "B")
+	defer b.Close()
+
+	master := make(chan string, 1)
+	master <- a.Addr().String()
+	switched := make(chan struct{})
+
+	sentinel := newFakeRedis(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "SENTINEL":
+			addr := <-master
+			master <- addr
+			host, port, _ := net.SplitHostPort(addr)
+			return array(bulk(host), bulk(port))
+
+		case "SUBSCRIBE":
+			<-switched
+			old := <-master
+			master <- b.Addr().String()
+
+			oh, op, _ := net.SplitHostPort(old)
+			nh, np, _ := net.SplitHostPort(b.Addr().String())
+			return array(bulk("subscribe"), bulk(switchMasterChannel), ":1\r\n") +
+				array(bulk("message"), bulk(switchMasterChannel), bulk(strings.Join([]string{"mymaster", oh, op, nh, np}, " ")))
+		}
+		return "-ERR unknown command\r\n"
+	})
+	defer sentinel.Close()
+
+	rp, err := NewRedisPool("redis+sentinel://" + sentinel.Addr().String() + ",127.0.0.1:1/mymaster?healthCheck=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if v, err := rp.Get("k").String(); err != nil || v != "A" {
+		t.Fatalf("Get before switch = %q, %v", v, err)
+	}
+
+	close(switched)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		v, err := rp.Get("k").String()
+		if err == nil && v == "B" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pool did not follow +switch-master, last reply %q, %v", v, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestParseSentinelURL(t *testing.T) {
+	opt, err := parsePoolOption("redis+sentinel://:pwd@10.0.0.1,10.0.0.2:26380/mymaster/3?sentinelPassword=s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := opt.Sentinel
+	if s == nil || s.MasterName != "mymaster" {
+		t.Fatalf("unexpected sentinel %+v", s)
+	}
+	if addrs := s.Addrs(); len(addrs) != 2 || addrs[0] != "10.0.0.1:26379" || addrs[1] != "10.0.0.2:26380" {
+		t.Errorf("unexpected sentinel addrs %v", addrs)
+	}
+
+	if _, err := parsePoolOption("redis+sentinel://10.0.0.1/"); err == nil {
+		t.Error("expected error for missing master name")
+	}
+}
+
+func TestSharedSentinel(t *testing.T) {
+	s := NewSentinel([]string{"127.0.0.1:1"}, "mymaster")
+	defer s.Close()
+
+	a, err := NewRedisPoolWithOptions("", WithSentinel(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewRedisPoolWithOptions("", WithSentinel(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	a.Close()
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		t.Error("closing one pool closed the shared sentinel")
+	}
+
+	rp, err := NewRedisPool("redis+sentinel://127.0.0.1:1/mymaster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	own := rp.sentinel
+	rp.Close()
+	own.mu.Lock()
+	defer own.mu.Unlock()
+	if !own.closed {
+		t.Error("sentinel created from URL was not closed with the pool")
+	}
+}
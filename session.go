@@ -0,0 +1,113 @@
+package mredis
+
+import (
+	"context"
+	"github.com/gomodule/redigo/redis"
+	"strings"
+	"time"
+)
+
+// 修改连接状态的命令, Session 结束后该连接不再放回连接池
+var connStateCommands = map[string]bool{
+	"SELECT":    true,
+	"CLIENT":    true,
+	"AUTH":      true,
+	"HELLO":     true,
+	"READONLY":  true,
+	"READWRITE": true,
+}
+
+/*
+	Session 固定在一条连接上的命令集合, 命令方法与 RedisPool 相同
+
+	用于 SELECT、CLIENT SETNAME、阻塞读取、WATCH 等需要在同一条连接上执行多条命令的场景,
+	只在 WithConn 的回调中有效, 不要保存或者在多个 goroutine 中同时使用
+*/
+type Session struct {
+	cmdable
+	objects
+	rp    *RedisPool
+	ctx   context.Context
+	conn  *sessionConn
+	dirty bool // 执行过修改连接状态的命令
+}
+
+func (s *Session) do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	return s.rp.exec(s.conn, ctx, cmd, args...)
+}
+
+/*
+	sessionConn 会话的连接, 命令方法、Tx 和 Conn() 都通过它执行,
+	记录修改连接状态的命令, 直接使用 Conn() 执行的 SELECT 等同样会让连接不再复用
+*/
+type sessionConn struct {
+	wrappedConn
+	s *Session
+}
+
+func (c *sessionConn) mark(cmd string) {
+	if connStateCommands[strings.ToUpper(cmd)] {
+		c.s.dirty = true
+	}
+}
+
+func (c *sessionConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	c.mark(cmd)
+	return c.Conn.Do(cmd, args...)
+}
+
+func (c *sessionConn) Send(cmd string, args ...interface{}) error {
+	c.mark(cmd)
+	return c.Conn.Send(cmd, args...)
+}
+
+func (c *sessionConn) DoContext(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	c.mark(cmd)
+	return c.wrappedConn.DoContext(ctx, cmd, args...)
+}
+
+func (c *sessionConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	c.mark(cmd)
+	return c.wrappedConn.DoWithTimeout(timeout, cmd, args...)
+}
+
+// 在这条连接上执行 MULTI/EXEC, 之前用 Do("WATCH", ...) 监视的 key 被修改时返回 ErrTxConflict
+func (s *Session) Tx(fn func(tx *Tx) error) error {
+	return runTx(newTx(s.ctx, s.conn), fn)
+}
+
+// 底层连接, 用于 mredis 没有封装的 redigo 功能; 在上面执行的 SELECT 等命令同样会让连接不再复用
+func (s *Session) Conn() redis.Conn {
+	return s.conn
+}
+
+/*
+	WithConn 从连接池取一条连接, 回调中的所有命令都在这条连接上执行, 回调返回后归还;
+	执行过 SELECT、CLIENT 等修改连接状态的命令时关闭该连接, 不影响连接池中的其他连接
+
+	rp.WithConn(ctx, func(c *Session) error {
+		if err := c.Do("SELECT", 2).Error(); err != nil {
+			return err
+		}
+		return c.Set("key", "value")
+	})
+*/
+func (rp *RedisPool) WithConn(ctx context.Context, fn func(c *Session) error) error {
+	conn, err := rp.getConnCtx(ctx)
+	if err != nil {
+		rp.stats.observe(0, err)
+		return err
+	}
+	defer conn.Close()
+
+	s := &Session{rp: rp, ctx: ctx}
+	s.conn = &sessionConn{wrappedConn: wrappedConn{conn}, s: s}
+	s.cmdable = s.do
+	s.objects = objects{c: s.cmdable, codec: rp.codec}
+
+	err = fn(s)
+	if s.dirty {
+		forceClose(conn)
+	}
+	return err
+}
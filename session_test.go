@@ -0,0 +1,108 @@
+package mredis
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestWithConn(t *testing.T) {
+	var quits int32
+	srv := newFakeRedis(t, func(args []string) string {
+		switch args[0] {
+		case "QUIT":
+			atomic.AddInt32(&quits, 1)
+		case "GET":
+			return bulk("v")
+		case "INCR":
+			return "+QUEUED\r\n"
+		case "EXEC":
+			return array(":1\r\n")
+		}
+		return "+OK\r\n"
+	})
+	defer srv.Close()
+
+	var dials int32
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(),
+		WithDialer(func(ctx context.Context, network, address string, options ...redis.DialOption) (redis.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return redis.DialContext(ctx, network, address, options...)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	var first redis.Conn
+	err = rp.WithConn(context.Background(), func(c *Session) error {
+		first = c.Conn()
+		if err := c.Set("k", "v"); err != nil {
+			return err
+		}
+		v, err := c.Get("k").String()
+		if err != nil || v != "v" {
+			t.Errorf("Get = %q, %v", v, err)
+		}
+		return c.Tx(func(tx *Tx) error {
+			tx.Incr("n")
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == nil || atomic.LoadInt32(&dials) != 1 {
+		t.Fatalf("dials = %d, want 1", dials)
+	}
+
+	// 修改过连接状态, 连接不再复用
+	err = rp.WithConn(context.Background(), func(c *Session) error {
+		return c.Do("SELECT", 2).Error()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := rp.Get("k").String(); err != nil || v != "v" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if d := atomic.LoadInt32(&dials); d != 2 {
+		t.Errorf("dials = %d, want 2", d)
+	}
+	// 通过 Conn() 和 Tx 直接执行的 SELECT 同样让连接不再复用
+	err = rp.WithConn(context.Background(), func(c *Session) error {
+		_, err := c.Conn().Do("SELECT", 3)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp.Get("k")
+	if d := atomic.LoadInt32(&dials); d != 3 {
+		t.Errorf("dials after Conn().Do(SELECT) = %d, want 3", d)
+	}
+	err = rp.WithConn(context.Background(), func(c *Session) error {
+		return c.Tx(func(tx *Tx) error {
+			tx.Do("SELECT", 4)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp.Get("k")
+	if d := atomic.LoadInt32(&dials); d != 4 {
+		t.Errorf("dials after Tx SELECT = %d, want 4", d)
+	}
+
+	// 连接由客户端直接关闭, 不依赖服务端处理 QUIT
+	if n := atomic.LoadInt32(&quits); n != 0 {
+		t.Errorf("QUIT sent %d times", n)
+	}
+	if n := rp.ActiveCount(); n != 1 {
+		t.Errorf("ActiveCount = %d, want 1", n)
+	}
+}
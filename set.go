@@ -1,78 +1,87 @@
 package mredis
 
 import (
+	"context"
 	redigo "github.com/gomodule/redigo/redis"
 )
 
 // args : key, val1, val2, val3....
-func (rp *RedisPool) SAdd(args ...interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SAdd(args ...interface{}) (e error) {
+	return c.SAddCtx(context.Background(), args...)
+}
 
-	_, e = conn.Do("SADD", args...)
+func (c cmdable) SAddCtx(ctx context.Context, args ...interface{}) (e error) {
+	_, e = c(ctx, "SADD", args...)
 	return
 }
 
 // args : key, val1, val2, val3....
 // return the values count which be added to set
-func (rp *RedisPool) SAddWithReturn(args ...interface{}) (int64, error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SAddWithReturn(args ...interface{}) (int64, error) {
+	return c.SAddWithReturnCtx(context.Background(), args...)
+}
 
-	return redigo.Int64(conn.Do("SADD", args...))
+func (c cmdable) SAddWithReturnCtx(ctx context.Context, args ...interface{}) (int64, error) {
+	return redigo.Int64(c(ctx, "SADD", args...))
 }
 
 // args : key, val1, val2, val3...
-func (rp *RedisPool) SRem(args ...interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SRem(args ...interface{}) (e error) {
+	return c.SRemCtx(context.Background(), args...)
+}
 
-	_, e = conn.Do("SREM", args...)
+func (c cmdable) SRemCtx(ctx context.Context, args ...interface{}) (e error) {
+	_, e = c(ctx, "SREM", args...)
 	return
 }
 
 // args : key, val1, val2, val3...
 // return the values count be removed form set
-func (rp *RedisPool) SRemWithReturn(args ...interface{}) (int64, error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SRemWithReturn(args ...interface{}) (int64, error) {
+	return c.SRemWithReturnCtx(context.Background(), args...)
+}
 
-	return redigo.Int64(conn.Do("SREM", args...))
+func (c cmdable) SRemWithReturnCtx(ctx context.Context, args ...interface{}) (int64, error) {
+	return redigo.Int64(c(ctx, "SREM", args...))
 }
 
-func (rp *RedisPool) SIsMember(key interface{}, value interface{}) (isMember bool, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SIsMember(key interface{}, value interface{}) (isMember bool, e error) {
+	return c.SIsMemberCtx(context.Background(), key, value)
+}
 
-	return redigo.Bool(conn.Do("SISMEMBER", key, value))
+func (c cmdable) SIsMemberCtx(ctx context.Context, key interface{}, value interface{}) (isMember bool, e error) {
+	return redigo.Bool(c(ctx, "SISMEMBER", key, value))
 }
 
 /*
 SMembers获取某个key下的所有元素
 */
-func (rp *RedisPool) SMembers(key interface{}) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SMembers(key interface{}) *Reply {
+	return c.SMembersCtx(context.Background(), key)
+}
 
-	return reply(conn.Do("SMEMBERS", key))
+func (c cmdable) SMembersCtx(ctx context.Context, key interface{}) *Reply {
+	return reply(c(ctx, "SMEMBERS", key))
 }
 
 /*
 SCard获取某个key下的元素数量
 */
-func (rp *RedisPool) SCard(key interface{}) (count int64, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SCard(key interface{}) (count int64, e error) {
+	return c.SCardCtx(context.Background(), key)
+}
 
-	return redigo.Int64(conn.Do("SCARD", key))
+func (c cmdable) SCardCtx(ctx context.Context, key interface{}) (count int64, e error) {
+	return redigo.Int64(c(ctx, "SCARD", key))
 }
 
 /*
 SRandMembers获取某个key下的随机count 个元素
 */
-func (rp *RedisPool) SRandMembers(key interface{}, count int) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) SRandMembers(key interface{}, count int) *Reply {
+	return c.SRandMembersCtx(context.Background(), key, count)
+}
 
-	return reply(conn.Do("SRANDMEMBER", key, count))
-}
\ No newline at end of file
+func (c cmdable) SRandMembersCtx(ctx context.Context, key interface{}, count int) *Reply {
+	return reply(c(ctx, "SRANDMEMBER", key, count))
+}
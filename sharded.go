@@ -0,0 +1,330 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"github.com/gomodule/redigo/redis"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const ShardReplicasDefault = 160 // 每个分片在哈希环上的虚拟节点数
+
+var ErrNoShard = errors.New("mredis: no shard available")
+
+/*
+	一致性哈希环, 虚拟节点按分片名生成, 增删分片只影响相邻区间的 key
+
+	虚拟节点冲突时名字小的分片优先, 增删分片后按排序的名字重建,
+	所以 key 的分布只由分片名决定, 与添加顺序无关
+*/
+type hashRing struct {
+	replicas int
+	names    []string // 有序
+	points   []uint32
+	owners   map[uint32]string
+}
+
+func newHashRing(replicas int, names ...string) *hashRing {
+	r := &hashRing{replicas: replicas}
+	r.names = append(r.names, names...)
+	sort.Strings(r.names)
+	r.build()
+	return r
+}
+
+func (r *hashRing) build() {
+	r.points = r.points[:0]
+	r.owners = make(map[uint32]string, r.replicas*len(r.names))
+	for _, name := range r.names {
+		for i := 0; i < r.replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(name + "#" + strconv.Itoa(i)))
+			if _, ok := r.owners[h]; ok {
+				continue
+			}
+			r.owners[h] = name
+			r.points = append(r.points, h)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+func (r *hashRing) add(name string) {
+	i := sort.SearchStrings(r.names, name)
+	if i < len(r.names) && r.names[i] == name {
+		return
+	}
+	r.names = append(r.names, "")
+	copy(r.names[i+1:], r.names[i:])
+	r.names[i] = name
+	r.build()
+}
+
+// 被移除分片的虚拟节点如果与其他分片冲突, 重建后归还给其他分片
+func (r *hashRing) remove(name string) {
+	i := sort.SearchStrings(r.names, name)
+	if i == len(r.names) || r.names[i] != name {
+		return
+	}
+	r.names = append(r.names[:i], r.names[i+1:]...)
+	r.build()
+}
+
+// key 中有 {tag} 时按 tag 定位
+func (r *hashRing) get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	h := crc32.ChecksumIEEE([]byte(hashTag(key)))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+/*
+	ShardedPool 客户端分片, 命令方法与 RedisPool 相同
+
+	按第一个 key 在一致性哈希环上选择分片, 带 {tag} 的 key 按 tag 定位,
+	MGet、MSet、Del、DelWithReturn 按分片拆分后合并结果, Keys 合并所有分片的结果;
+	对象命令使用 WithShardedCodec 指定的 codec, 默认 JSONCodec, 不继承各分片的 codec
+*/
+type ShardedPool struct {
+	cmdable
+	objects
+
+	mu     sync.RWMutex
+	ring   *hashRing
+	shards map[string]*RedisPool
+}
+
+type ShardedOption func(s *ShardedPool)
+
+// 对象命令默认的 codec, 所有分片使用同一个
+func WithShardedCodec(codec Codec) ShardedOption {
+	return func(s *ShardedPool) { s.objects.codec = staticCodec(codec) }
+}
+
+/*
+	shards: 分片名 -> 连接池, 分片名决定 key 的分布, 更换地址时保持名字不变
+*/
+func NewShardedPool(shards map[string]*RedisPool, opts ...ShardedOption) *ShardedPool {
+	s := &ShardedPool{
+		shards: make(map[string]*RedisPool, len(shards)),
+	}
+	names := make([]string, 0, len(shards))
+	for name, rp := range shards {
+		s.shards[name] = rp
+		names = append(names, name)
+	}
+	s.ring = newHashRing(ShardReplicasDefault, names...)
+
+	s.cmdable = s.do
+	s.objects.c = s.cmdable
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// urls: 分片名 -> redis url
+func NewShardedPoolFromURLs(urls map[string]string, opts ...ShardedOption) (*ShardedPool, error) {
+	shards := make(map[string]*RedisPool, len(urls))
+	for name, u := range urls {
+		rp, err := NewRedisPool(u)
+		if err != nil {
+			for _, rp := range shards {
+				rp.Close()
+			}
+			return nil, err
+		}
+		shards[name] = rp
+	}
+
+	return NewShardedPool(shards, opts...), nil
+}
+
+// 增加分片, 同名分片会被替换, 返回被替换的连接池(由调用方关闭)
+func (s *ShardedPool) AddShard(name string, rp *RedisPool) *RedisPool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.shards[name]
+	s.shards[name] = rp
+	if !ok {
+		s.ring.add(name)
+	}
+	return old
+}
+
+// 移除分片, 返回被移除的连接池(由调用方关闭)
+func (s *ShardedPool) RemoveShard(name string) *RedisPool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rp, ok := s.shards[name]
+	if !ok {
+		return nil
+	}
+	delete(s.shards, name)
+	s.ring.remove(name)
+	return rp
+}
+
+// key 所在的分片名
+func (s *ShardedPool) ShardName(key interface{}) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.ring.get(keyString(key))
+}
+
+// key 所在的分片
+func (s *ShardedPool) Shard(key interface{}) *RedisPool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.shards[s.ring.get(keyString(key))]
+}
+
+func (s *ShardedPool) Shards() map[string]*RedisPool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shards := make(map[string]*RedisPool, len(s.shards))
+	for name, rp := range s.shards {
+		shards[name] = rp
+	}
+	return shards
+}
+
+// 按第一个参数选择分片, PUBLISH 按 channel 选择, 脚本按第一个 key 选择; 没有参数的命令使用任意一个分片
+func (s *ShardedPool) do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	var key interface{}
+	ok := len(args) > 0
+	if ok {
+		key = args[0]
+	}
+	if isScriptCommand(cmd) {
+		key, ok = scriptKey(args)
+	}
+
+	var rp *RedisPool
+	if ok {
+		rp = s.Shard(key)
+	} else {
+		for _, shard := range s.Shards() {
+			rp = shard
+			break
+		}
+	}
+
+	if rp == nil {
+		return nil, ErrNoShard
+	}
+	return rp.do(ctx, cmd, args...)
+}
+
+func (s *ShardedPool) Close() error {
+	var err error
+	for _, rp := range s.Shards() {
+		if e := rp.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+/*
+	--------------- 跨分片的多 key 命令 -----------------------
+*/
+
+func (s *ShardedPool) groupByShard(args []interface{}, step int) []*argGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return groupArgs(args, step, func(key string) interface{} {
+		return s.shards[s.ring.get(key)]
+	})
+}
+
+func (s *ShardedPool) scatter(ctx context.Context, cmd string, groups []*argGroup) ([]interface{}, error) {
+	return scatter(groups, func(g *argGroup) (interface{}, error) {
+		rp, _ := g.target.(*RedisPool)
+		if rp == nil {
+			return nil, ErrNoShard
+		}
+		return rp.do(ctx, cmd, g.args...)
+	})
+}
+
+func (s *ShardedPool) MGet(keys ...interface{}) *Reply {
+	return s.MGetCtx(context.Background(), keys...)
+}
+
+func (s *ShardedPool) MGetCtx(ctx context.Context, keys ...interface{}) *Reply {
+	groups := s.groupByShard(keys, 1)
+	replies, err := s.scatter(ctx, "MGET", groups)
+	if err != nil {
+		return reply(nil, err)
+	}
+
+	return reply(gatherValues(len(keys), groups, replies))
+}
+
+func (s *ShardedPool) MSet(kvs ...interface{}) error {
+	return s.MSetCtx(context.Background(), kvs...)
+}
+
+func (s *ShardedPool) MSetCtx(ctx context.Context, kvs ...interface{}) error {
+	if len(kvs)%2 != 0 {
+		return errors.New("invalid arguments number")
+	}
+
+	_, err := s.scatter(ctx, "MSET", s.groupByShard(kvs, 2))
+	return err
+}
+
+func (s *ShardedPool) Del(keys ...interface{}) error {
+	return s.DelCtx(context.Background(), keys...)
+}
+
+func (s *ShardedPool) DelCtx(ctx context.Context, keys ...interface{}) error {
+	_, err := s.DelWithReturnCtx(ctx, keys...)
+	return err
+}
+
+func (s *ShardedPool) DelWithReturn(keys ...interface{}) (int, error) {
+	return s.DelWithReturnCtx(context.Background(), keys...)
+}
+
+func (s *ShardedPool) DelWithReturnCtx(ctx context.Context, keys ...interface{}) (int, error) {
+	replies, err := s.scatter(ctx, "DEL", s.groupByShard(keys, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	return sumInts(replies)
+}
+
+// 在所有分片上执行 KEYS 并合并
+func (s *ShardedPool) Keys(pattern interface{}) *Reply {
+	return s.KeysCtx(context.Background(), pattern)
+}
+
+func (s *ShardedPool) KeysCtx(ctx context.Context, pattern interface{}) *Reply {
+	keys := make([]interface{}, 0, 64)
+	for _, rp := range s.Shards() {
+		values, err := redis.Values(rp.do(ctx, "KEYS", pattern))
+		if err != nil {
+			return reply(nil, err)
+		}
+		keys = append(keys, values...)
+	}
+
+	return reply(keys, nil)
+}
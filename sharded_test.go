@@ -0,0 +1,120 @@
+package mredis
+
+import (
+	"hash/crc32"
+	"strconv"
+	"testing"
+)
+
+func TestHashRingMinimalMove(t *testing.T) {
+	ring := newHashRing(ShardReplicasDefault, "s1", "s2", "s3")
+
+	const n = 10000
+	before := make([]string, n)
+	for i := range before {
+		before[i] = ring.get("key:" + strconv.Itoa(i))
+	}
+
+	ring.add("s4")
+	moved := 0
+	for i := range before {
+		owner := ring.get("key:" + strconv.Itoa(i))
+		if owner != before[i] {
+			moved++
+			if owner != "s4" {
+				t.Fatalf("key moved from %s to %s, want s4", before[i], owner)
+			}
+		}
+	}
+	// 理论上约 1/4 的 key 移动到新分片
+	if moved < n/8 || moved > n/2 {
+		t.Errorf("moved %d of %d keys after adding a shard", moved, n)
+	}
+
+	ring.remove("s4")
+	for i := range before {
+		if owner := ring.get("key:" + strconv.Itoa(i)); owner != before[i] {
+			t.Fatalf("key:%d on %s after removing s4, want %s", i, owner, before[i])
+		}
+	}
+
+	if ring.get("{user:1}.name") != ring.get("{user:1}.age") {
+		t.Error("hash tag keys should share a shard")
+	}
+}
+
+// "1fdc0ee9#0" 和 "5fb19e8e#0" 的 crc32 相同
+func TestHashRingCollision(t *testing.T) {
+	const a, b = "1fdc0ee9", "5fb19e8e"
+	h := crc32.ChecksumIEEE([]byte(a + "#0"))
+	if h != crc32.ChecksumIEEE([]byte(b+"#0")) {
+		t.Fatal("test names do not collide")
+	}
+
+	// 与添加顺序无关, 名字小的分片优先
+	for _, ring := range []*hashRing{newHashRing(1, a, b, "s3"), newHashRing(1, "s3", b, a)} {
+		if owner := ring.owners[h]; owner != a || len(ring.points) != 2 {
+			t.Errorf("collision owner = %s, points = %d, want %s, 2", owner, len(ring.points), a)
+		}
+	}
+	ring := newHashRing(1, "s3")
+	ring.add(b)
+	ring.add(a)
+	if owner := ring.owners[h]; owner != a {
+		t.Errorf("collision owner after add = %s, want %s", owner, a)
+	}
+
+	// 移除后冲突的虚拟节点归还给另一个分片
+	ring.remove(a)
+	if owner := ring.owners[h]; owner != b || len(ring.points) != 2 {
+		t.Errorf("collision owner after remove = %q, points = %d, want %s, 2", owner, len(ring.points), b)
+	}
+}
+
+func TestShardedPoolMultiKey(t *testing.T) {
+	shards := make(map[string]*RedisPool)
+	for _, name := range []string{"a", "b", "c"} {
+		node := newFakeNode(t, 0, clusterSlots-1)
+		defer node.Close()
+
+		rp, err := NewRedisPool("redis://" + node.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		shards[name] = rp
+	}
+
+	s := NewShardedPool(shards)
+	defer s.Close()
+
+	keys := make([]interface{}, 0, 20)
+	kvs := make([]interface{}, 0, 40)
+	for i := 0; i < 20; i++ {
+		k := "key:" + strconv.Itoa(i)
+		keys = append(keys, k)
+		kvs = append(kvs, k, "v"+strconv.Itoa(i))
+	}
+
+	if err := s.MSet(kvs...); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := s.MGet(keys...).Strings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if v != "v"+strconv.Itoa(i) {
+			t.Errorf("MGet[%d] = %q", i, v)
+		}
+	}
+
+	if v, err := s.Get("key:7").String(); err != nil || v != "v7" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+
+	n, err := s.DelWithReturn(keys...)
+	if err != nil || n != len(keys) {
+		t.Errorf("DelWithReturn = %d, %v", n, err)
+	}
+}
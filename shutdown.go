@@ -0,0 +1,198 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"github.com/gomodule/redigo/redis"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrPoolShutdown = errors.New("mredis: pool is shut down")
+
+// 会长时间阻塞连接的命令, Shutdown 时直接中断
+var blockingCommands = map[string]bool{
+	"BLPOP":      true,
+	"BRPOP":      true,
+	"BRPOPLPUSH": true,
+	"BLMOVE":     true,
+	"BZPOPMIN":   true,
+	"BZPOPMAX":   true,
+	"XREAD":      true,
+	"XREADGROUP": true,
+	"WAIT":       true,
+}
+
+func isBlocking(cmd string) bool {
+	return blockingCommands[strings.ToUpper(cmd)]
+}
+
+// 正在使用的连接数, Shutdown 等待其归零
+type inflight struct {
+	mu       sync.Mutex
+	count    int
+	shutdown bool
+	drained  chan struct{}
+	stopping chan struct{} // Shutdown 开始时关闭, 用于中断阻塞命令
+	subs     map[*pooledConn]struct{}
+}
+
+func newInflight() *inflight {
+	return &inflight{
+		drained:  make(chan struct{}),
+		stopping: make(chan struct{}),
+		subs:     make(map[*pooledConn]struct{}),
+	}
+}
+
+func (f *inflight) acquire() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.shutdown {
+		return ErrPoolShutdown
+	}
+	f.count++
+	return nil
+}
+
+// 正在执行的命令数
+func (f *inflight) active() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.count
+}
+
+// 订阅连接, Shutdown 时退订
+func (f *inflight) track(c *pooledConn) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.shutdown {
+		return ErrPoolShutdown
+	}
+	f.subs[c] = struct{}{}
+	return nil
+}
+
+func (f *inflight) release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.count--
+	if f.shutdown && f.count == 0 {
+		close(f.drained)
+	}
+}
+
+/*
+	Shutdown 优雅关闭连接池:
+	1. 之后的命令和取连接都返回 ErrPoolShutdown
+	2. BLPOP/BRPOP 等阻塞命令立即返回 ErrPoolShutdown, 订阅连接退订所有频道
+	3. 等待正在执行的命令完成, 订阅连接需要调用方 Close
+	4. 关闭所有连接
+	ctx 到期时不再等待, 直接关闭连接池并返回 ctx.Err(), 未完成的连接在归还时关闭
+*/
+func (rp *RedisPool) Shutdown(ctx context.Context) error {
+	f := rp.inflight
+
+	f.mu.Lock()
+	if f.shutdown {
+		f.mu.Unlock()
+		return ErrPoolShutdown
+	}
+	f.shutdown = true
+	close(f.stopping)
+	if f.count == 0 {
+		close(f.drained)
+	}
+	subs := make([]*pooledConn, 0, len(f.subs))
+	for c := range f.subs {
+		subs = append(subs, c)
+	}
+	f.mu.Unlock()
+
+	for _, c := range subs {
+		psc := redis.PubSubConn{Conn: c}
+		psc.Unsubscribe()
+		psc.PUnsubscribe()
+	}
+
+	var err error
+	select {
+	case <-f.drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if e := rp.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// 阻塞命令的 ctx 在 Shutdown 时取消
+func (rp *RedisPool) blockingContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-rp.inflight.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+func (rp *RedisPool) stopping() bool {
+	select {
+	case <-rp.inflight.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// 归还连接时通知 RedisPool, Shutdown 据此等待
+type pooledConn struct {
+	wrappedConn
+	rp   *RedisPool
+	once sync.Once
+}
+
+func (c *pooledConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		f := c.rp.inflight
+		f.mu.Lock()
+		delete(f.subs, c)
+		f.mu.Unlock()
+
+		f.release()
+	})
+	return err
+}
+
+// 包装 redis.Conn 时保留 ConnWithContext/ConnWithTimeout
+type wrappedConn struct {
+	redis.Conn
+}
+
+func (c wrappedConn) DoContext(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	return redis.DoContext(c.Conn, ctx, cmd, args...)
+}
+
+func (c wrappedConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	return redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+}
+
+func (c wrappedConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	return redis.ReceiveContext(c.Conn, ctx)
+}
+
+func (c wrappedConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	return redis.ReceiveWithTimeout(c.Conn, timeout)
+}
@@ -0,0 +1,61 @@
+package mredis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	srv := newFakeRedis(t, func(args []string) string {
+		switch args[0] {
+		case "BLPOP":
+			<-block
+			return "*-1\r\n"
+		case "GET":
+			time.Sleep(100 * time.Millisecond)
+			return bulk("v")
+		}
+		return "+OK\r\n"
+	})
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blpop := make(chan error, 1)
+	go func() {
+		err := rp.BLPop("queue", 0).Error()
+		blpop <- err
+	}()
+	get := make(chan error, 1)
+	go func() {
+		_, err := rp.Get("key").String()
+		get <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rp.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+
+	if err := <-blpop; err != ErrPoolShutdown {
+		t.Errorf("BLPOP = %v, want ErrPoolShutdown", err)
+	}
+	if err := <-get; err != nil {
+		t.Errorf("in-flight GET = %v, want nil", err)
+	}
+	if err := rp.Set("key", "v"); err != ErrPoolShutdown {
+		t.Errorf("Set after Shutdown = %v, want ErrPoolShutdown", err)
+	}
+	if _, err := rp.GetPubSubConn(); err != ErrPoolShutdown {
+		t.Errorf("GetPubSubConn after Shutdown = %v, want ErrPoolShutdown", err)
+	}
+}
@@ -0,0 +1,168 @@
+package mredis
+
+import (
+	"context"
+	"fmt"
+	"github.com/gomodule/redigo/redis"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats 连接池统计快照
+type PoolStats struct {
+	redis.PoolStats // ActiveCount, IdleCount, WaitCount, WaitDuration
+
+	InUseCount   int   // 正在使用的连接数
+	DialFailures int64 // 建立连接失败次数
+	Commands     int64 // 执行的命令数
+	Errors       int64 // 网络、连接池错误次数, 不包括 redis 返回的错误
+
+	AvgLatency  time.Duration // 命令平均往返时间
+	PingLatency time.Duration // 最近一次 Ping 的往返时间
+
+	Healthy             bool // 未启动健康检查时总是 true
+	ConsecutiveFailures int  // 健康检查连续失败次数
+}
+
+type poolStats struct {
+	dialFailures int64
+	commands     int64
+	errors       int64
+	latency      int64 // 累计纳秒
+	pingLatency  int64
+}
+
+func (s *poolStats) observe(d time.Duration, err error) {
+	atomic.AddInt64(&s.commands, 1)
+	atomic.AddInt64(&s.latency, int64(d))
+	if isConnError(err) {
+		atomic.AddInt64(&s.errors, 1)
+	}
+}
+
+func (rp *RedisPool) Stats() PoolStats {
+	s := PoolStats{
+		PoolStats:    rp.current().Stats(),
+		DialFailures: atomic.LoadInt64(&rp.stats.dialFailures),
+		Commands:     atomic.LoadInt64(&rp.stats.commands),
+		Errors:       atomic.LoadInt64(&rp.stats.errors),
+		PingLatency:  time.Duration(atomic.LoadInt64(&rp.stats.pingLatency)),
+		Healthy:      true,
+	}
+	s.InUseCount = s.ActiveCount - s.IdleCount
+	if s.Commands > 0 {
+		s.AvgLatency = time.Duration(atomic.LoadInt64(&rp.stats.latency) / s.Commands)
+	}
+
+	if h := rp.health; h != nil {
+		s.Healthy, s.ConsecutiveFailures = h.state()
+	}
+
+	return s
+}
+
+// Ping 执行一次 PING, 可用于就绪检查
+func (rp *RedisPool) Ping(ctx context.Context) error {
+	start := time.Now()
+	pong, err := redis.String(rp.do(ctx, "PING"))
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt64(&rp.stats.pingLatency, int64(time.Since(start)))
+
+	if pong != "PONG" {
+		return fmt.Errorf("mredis: unexpected PING reply %q", pong)
+	}
+	return nil
+}
+
+// 未启动健康检查时总是 true
+func (rp *RedisPool) Healthy() bool {
+	if rp.health == nil {
+		return true
+	}
+
+	healthy, _ := rp.health.state()
+	return healthy
+}
+
+/*
+	后台健康检查, 每 interval 执行一次 Ping, 连续失败 threshold 次标记为不健康,
+	成功一次恢复健康; 连接池 Close 时停止
+*/
+func WithHealthChecker(interval time.Duration, threshold int) Option {
+	return func(opt *poolOption) {
+		opt.PingInterval = interval
+		opt.PingThreshold = threshold
+	}
+}
+
+func newHealthChecker(threshold int) *healthChecker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	return &healthChecker{
+		threshold: threshold,
+		healthy:   true,
+		done:      make(chan struct{}),
+	}
+}
+
+type healthChecker struct {
+	threshold int
+
+	mu       sync.Mutex
+	healthy  bool
+	failures int
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (h *healthChecker) run(rp *RedisPool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		h.report(rp.Ping(ctx))
+		cancel()
+	}
+}
+
+func (h *healthChecker) report(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err == nil {
+		h.failures = 0
+		h.healthy = true
+		return
+	}
+
+	h.failures++
+	if h.failures >= h.threshold {
+		h.healthy = false
+	}
+}
+
+func (h *healthChecker) state() (bool, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.healthy, h.failures
+}
+
+func (h *healthChecker) stop() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
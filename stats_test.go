@@ -0,0 +1,60 @@
+package mredis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPoolStatsAndPing(t *testing.T) {
+	srv := newFakeRedis(t, func(args []string) string {
+		return "+PONG\r\n"
+	})
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if err := rp.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rp.Exists("k")
+
+	s := rp.Stats()
+	if s.Commands != 2 || s.Errors != 0 || s.ActiveCount != 1 || s.IdleCount != 1 || s.InUseCount != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.PingLatency <= 0 || !s.Healthy {
+		t.Errorf("unexpected ping stats %+v", s)
+	}
+}
+
+func TestHealthChecker(t *testing.T) {
+	srv := newFakeRedis(t, func(args []string) string {
+		return "+PONG\r\n"
+	})
+	addr := srv.Addr().String()
+	srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(addr, WithHealthChecker(10*time.Millisecond, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for rp.Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("pool still healthy after consecutive ping failures")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	s := rp.Stats()
+	if s.ConsecutiveFailures < 2 || s.DialFailures < 2 || s.Errors < 2 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
@@ -1,74 +1,84 @@
 package mredis
 
 import (
+	"context"
 	"errors"
 	redigo "github.com/gomodule/redigo/redis"
 )
 
-func (rp *RedisPool) Get(key interface{}) *Reply {
-	c := rp.getConn()
-	defer c.Close()
+func (c cmdable) Get(key interface{}) *Reply {
+	return c.GetCtx(context.Background(), key)
+}
 
-	return reply(c.Do("GET", key))
+func (c cmdable) GetCtx(ctx context.Context, key interface{}) *Reply {
+	return reply(c(ctx, "GET", key))
 }
 
-func (rp *RedisPool) Set(key interface{}, value interface{}) (e error) {
-	c := rp.getConn()
-	defer c.Close()
+func (c cmdable) Set(key interface{}, value interface{}) (e error) {
+	return c.SetCtx(context.Background(), key, value)
+}
 
-	_, e = c.Do("SET", key, value)
+func (c cmdable) SetCtx(ctx context.Context, key interface{}, value interface{}) (e error) {
+	_, e = c(ctx, "SET", key, value)
 	return
 }
 
-func (rp *RedisPool) SetEx(key interface{}, seconds int, value interface{}) (e error) {
-	c := rp.getConn()
-	defer c.Close()
+func (c cmdable) SetEx(key interface{}, seconds int, value interface{}) (e error) {
+	return c.SetExCtx(context.Background(), key, seconds, value)
+}
 
-	_, e = c.Do("SETEX", key, seconds, value)
+func (c cmdable) SetExCtx(ctx context.Context, key interface{}, seconds int, value interface{}) (e error) {
+	_, e = c(ctx, "SETEX", key, seconds, value)
 	return
 }
 
-func (rp *RedisPool) SetNx(key interface{}, value interface{}) bool {
-	c := rp.getConn()
-	defer c.Close()
+func (c cmdable) SetNx(key interface{}, value interface{}) bool {
+	return c.SetNxCtx(context.Background(), key, value)
+}
 
-	val, _ := redigo.Int(c.Do("SETNX", key, value))
+func (c cmdable) SetNxCtx(ctx context.Context, key interface{}, value interface{}) bool {
+	val, _ := redigo.Int(c(ctx, "SETNX", key, value))
 	return val == SetNxSuccess
 }
 
-func (rp *RedisPool) Incr(key interface{}) *Reply {
-	c := rp.getConn()
-	defer c.Close()
+func (c cmdable) Incr(key interface{}) *Reply {
+	return c.IncrCtx(context.Background(), key)
+}
 
-	return reply(c.Do("INCR", key))
+func (c cmdable) IncrCtx(ctx context.Context, key interface{}) *Reply {
+	return reply(c(ctx, "INCR", key))
 }
 
-func (rp *RedisPool) IncrBy(key interface{}, value interface{}) *Reply {
-	c := rp.getConn()
-	defer c.Close()
+func (c cmdable) IncrBy(key interface{}, value interface{}) *Reply {
+	return c.IncrByCtx(context.Background(), key, value)
+}
 
-	return reply(c.Do("INCRBY", key, value))
+func (c cmdable) IncrByCtx(ctx context.Context, key interface{}, value interface{}) *Reply {
+	return reply(c(ctx, "INCRBY", key, value))
 }
 
 // 批量获取 keys = k1,k2,k3....
-func (rp *RedisPool) MGet(keys ...interface{}) *Reply {
-	c := rp.getConn()
-	defer c.Close()
+func (c cmdable) MGet(keys ...interface{}) *Reply {
+	return c.MGetCtx(context.Background(), keys...)
+}
 
-	return reply(c.Do("MGET", keys...))
+func (c cmdable) MGetCtx(ctx context.Context, keys ...interface{}) *Reply {
+	return reply(c(ctx, "MGET", keys...))
 }
 
 /*
 批量设置
 kvs : < key value > 序列
 */
-func (rp *RedisPool) MSet(kvs ...interface{}) (e error) {
+func (c cmdable) MSet(kvs ...interface{}) (e error) {
+	return c.MSetCtx(context.Background(), kvs...)
+}
+
+func (c cmdable) MSetCtx(ctx context.Context, kvs ...interface{}) (e error) {
 	if len(kvs)%2 != 0 {
 		return errors.New("invalid arguments number")
 	}
-	c := rp.getConn()
-	defer c.Close()
 
-	_, e = c.Do("MSET", kvs...)
+	_, e = c(ctx, "MSET", kvs...)
 	return
 }
@@ -0,0 +1,336 @@
+package mredis
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	redigo "github.com/gomodule/redigo/redis"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+	结构体与 hash 互相转换, 字段名由 redis tag 指定, tag 规则参考 redigo.ScanStruct,
+	转换由 mredis 实现, 另外支持 omitempty、指针字段和 encoding.TextMarshaler:
+
+	type User struct {
+		ID       int64      `redis:"id"`
+		Name     string     `redis:"name"`
+		Nick     *string    `redis:"nick"`              // nil 时不写入
+		Score    float64    `redis:"score,omitempty"`   // 零值时不写入
+		Created  time.Time  `redis:"created"`           // 实现 encoding.TextMarshaler 的类型按文本保存
+		Tags     TagSet     `redis:"tags"`              // 实现 redigo.Argument/redigo.Scanner 的类型优先使用 RedisArg/RedisScan
+		Password string     `redis:"-"`                 // 忽略
+		Profile                                        // 匿名结构体的字段展开
+		*Audit                                         // 匿名结构体指针同样展开, nil 时不写入, 读取时分配
+	}
+
+	rp.HSetStruct("user:1", &u)
+	err := rp.HGetAll("user:1").ScanStruct(&u)
+*/
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	argumentType        = reflect.TypeOf((*redigo.Argument)(nil)).Elem()
+	scannerType         = reflect.TypeOf((*redigo.Scanner)(nil)).Elem()
+)
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type structSpec struct {
+	fields []*structField
+	byName map[string]*structField
+}
+
+var structSpecs sync.Map // reflect.Type -> *structSpec
+
+func structSpecOf(t reflect.Type) *structSpec {
+	if ss, ok := structSpecs.Load(t); ok {
+		return ss.(*structSpec)
+	}
+
+	ss := &structSpec{byName: make(map[string]*structField)}
+	compileStructSpec(t, nil, ss)
+	structSpecs.Store(t, ss)
+	return ss
+}
+
+func compileStructSpec(t reflect.Type, index []int, ss *structSpec) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("redis")
+		if tag == "-" {
+			continue
+		}
+
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+
+		// 没有 tag 的匿名结构体和结构体指针展开, 实现了 TextUnmarshaler 或 redigo.Scanner 的(如 time.Time)作为普通字段;
+		// 未导出类型的指针无法分配, 同 encoding/json 忽略
+		if ft := f.Type; f.Anonymous && tag == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct &&
+				!reflect.PtrTo(ft).Implements(textUnmarshalerType) && !reflect.PtrTo(ft).Implements(scannerType) {
+				if f.Type.Kind() == reflect.Ptr && f.PkgPath != "" {
+					continue
+				}
+				compileStructSpec(ft, idx, ss)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		field := &structField{name: f.Name, index: idx}
+		if tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				field.name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					field.omitEmpty = true
+				}
+			}
+		}
+
+		// 外层同名字段优先
+		if _, ok := ss.byName[field.name]; ok {
+			continue
+		}
+		ss.byName[field.name] = field
+		ss.fields = append(ss.fields, field)
+	}
+}
+
+/*
+	fieldByIndex 同 reflect.Value.FieldByIndex, 经过 nil 的匿名结构体指针时:
+	alloc 为 true 分配新的结构体, 否则返回 false
+*/
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, errors.New("mredis: struct pointer is nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("mredis: %T is not a struct", v)
+	}
+	return rv, nil
+}
+
+// 结构体展开成 field value [field value ...]
+func structArgs(v interface{}) ([]interface{}, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := structSpecOf(rv.Type())
+	args := make([]interface{}, 0, 2*len(ss.fields))
+	for _, f := range ss.fields {
+		fv, ok := fieldByIndex(rv, f.index, false)
+		if !ok {
+			continue
+		}
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		value, err := encodeField(fv)
+		if err != nil {
+			return nil, fmt.Errorf("mredis: field %s: %v", f.name, err)
+		}
+		args = append(args, f.name, value)
+	}
+	return args, nil
+}
+
+// redigo.Argument 的结果原样作为参数, 由 redigo 转换
+func encodeField(v reflect.Value) (interface{}, error) {
+	if v.Type().Implements(argumentType) {
+		return v.Interface().(redigo.Argument).RedisArg(), nil
+	}
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(argumentType) {
+		return v.Addr().Interface().(redigo.Argument).RedisArg(), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		return v.Interface().(encoding.TextMarshaler).MarshalText()
+	}
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+		return v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// src 为 redis 返回的原始值, redigo.Scanner 直接使用, 其他类型按文本解析
+func decodeField(v reflect.Value, src interface{}) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeField(v.Elem(), src)
+	}
+
+	if reflect.PtrTo(v.Type()).Implements(scannerType) {
+		return v.Addr().Interface().(redigo.Scanner).RedisScan(src)
+	}
+	s, err := redigo.Bytes(src, nil)
+	if err != nil {
+		return err
+	}
+
+	if reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(s)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(s))
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(string(s))
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(string(s), 10, v.Type().Bits())
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		n, err = strconv.ParseUint(string(s), 10, v.Type().Bits())
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(string(s), v.Type().Bits())
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.SetBytes(append([]byte(nil), s...))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return err
+}
+
+/*
+	ScanStruct 把 HGETALL 的结果(field value 交替)写入 dst 指向的结构体,
+	hash 中没有的字段保持原值; key 不存在(结果为空)时返回 redigo.ErrNil
+*/
+func (r *Reply) ScanStruct(dst interface{}) error {
+	values, err := redigo.Values(r.Raw, r.Err)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return redigo.ErrNil
+	}
+	if len(values)%2 != 0 {
+		return errors.New("mredis: ScanStruct expects even number of values in result")
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("mredis: ScanStruct dst must be a non-nil pointer, got %T", dst)
+	}
+	rv, err = structValue(dst)
+	if err != nil {
+		return err
+	}
+
+	ss := structSpecOf(rv.Type())
+	for i := 0; i < len(values); i += 2 {
+		name, err := redigo.String(values[i], nil)
+		if err != nil {
+			return err
+		}
+		f, ok := ss.byName[name]
+		if !ok {
+			continue
+		}
+
+		fv, _ := fieldByIndex(rv, f.index, true)
+		if err := decodeField(fv, values[i+1]); err != nil {
+			return fmt.Errorf("mredis: field %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// 按 redis tag 展开结构体后 HMSET, 所有字段都被忽略时不执行
+func (c cmdable) HSetStruct(key interface{}, v interface{}) error {
+	return c.HSetStructCtx(context.Background(), key, v)
+}
+
+func (c cmdable) HSetStructCtx(ctx context.Context, key interface{}, v interface{}) error {
+	args, err := structArgs(v)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	_, err = c(ctx, "HMSET", append([]interface{}{key}, args...)...)
+	return err
+}
@@ -0,0 +1,163 @@
+package mredis
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+type testProfile struct {
+	City string `redis:"city"`
+}
+
+// 匿名结构体指针, 未导出类型的指针不展开, 所以测试类型需要导出
+type TestAudit struct {
+	Source string `redis:"source"`
+}
+
+// 逗号分隔保存, 通过 redigo.Argument/redigo.Scanner 转换
+type testTags []string
+
+func (t testTags) RedisArg() interface{} {
+	return strings.Join(t, ",")
+}
+
+func (t *testTags) RedisScan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("testTags: cannot scan %T", src)
+	}
+	*t = strings.Split(string(b), ",")
+	return nil
+}
+
+type testUser struct {
+	ID       int64     `redis:"id"`
+	Name     string    `redis:"name"`
+	Nick     *string   `redis:"nick"`
+	Score    float64   `redis:"score,omitempty"`
+	Admin    bool      `redis:"admin"`
+	Created  time.Time `redis:"created"`
+	Addr     net.IP    `redis:"addr"`
+	Tags     testTags  `redis:"tags"`
+	Password string    `redis:"-"`
+	testProfile
+	*TestAudit
+}
+
+func newFakeHash(t *testing.T) (*fakeRedis, func() map[string]string) {
+	var mu sync.Mutex
+	hash := make(map[string]string)
+	srv := newFakeRedis(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch args[0] {
+		case "HMSET":
+			for i := 2; i+1 < len(args); i += 2 {
+				hash[args[i]] = args[i+1]
+			}
+			return "+OK\r\n"
+		case "HGETALL":
+			items := make([]string, 0, 2*len(hash))
+			for k, v := range hash {
+				items = append(items, bulk(k), bulk(v))
+			}
+			return array(items...)
+		}
+		return "+OK\r\n"
+	})
+
+	return srv, func() map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		m := make(map[string]string, len(hash))
+		for k, v := range hash {
+			m[k] = v
+		}
+		return m
+	}
+}
+
+func TestHSetStruct(t *testing.T) {
+	srv, stored := newFakeHash(t)
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	var empty testUser
+	if err := rp.HGetAll("user:1").ScanStruct(&empty); err != redis.ErrNil {
+		t.Errorf("ScanStruct on missing key = %v, want ErrNil", err)
+	}
+
+	nick := "bob"
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	in := testUser{
+		ID:          7,
+		Name:        "Robert",
+		Nick:        &nick,
+		Admin:       true,
+		Created:     created,
+		Addr:        net.ParseIP("10.0.0.1"),
+		Tags:        testTags{"go", "redis"},
+		Password:    "secret",
+		testProfile: testProfile{City: "Paris"},
+	}
+	if err := rp.HSetStruct("user:1", &in); err != nil {
+		t.Fatal(err)
+	}
+
+	m := stored()
+	if _, ok := m["score"]; ok {
+		t.Error("omitempty field was stored")
+	}
+	if _, ok := m["Password"]; ok {
+		t.Error("ignored field was stored")
+	}
+	if m["created"] != "2024-05-01T12:30:00Z" || m["admin"] != "1" || m["city"] != "Paris" || m["addr"] != "10.0.0.1" || m["tags"] != "go,redis" {
+		t.Errorf("stored hash = %v", m)
+	}
+
+	var out testUser
+	if err := rp.HGetAll("user:1").ScanStruct(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != 7 || out.Name != "Robert" || out.Nick == nil || *out.Nick != "bob" || !out.Admin ||
+		!out.Created.Equal(created) || !out.Addr.Equal(in.Addr) || out.City != "Paris" || out.Password != "" ||
+		len(out.Tags) != 2 || out.Tags[1] != "redis" {
+		t.Errorf("ScanStruct = %+v", out)
+	}
+
+	// 匿名结构体指针: nil 时不写入, 读取时分配
+	if _, ok := m["source"]; ok {
+		t.Error("nil embedded pointer was stored")
+	}
+	in.TestAudit = &TestAudit{Source: "import"}
+	if err := rp.HSetStruct("user:1", &in); err != nil {
+		t.Fatal(err)
+	}
+	if m := stored(); m["source"] != "import" {
+		t.Errorf("stored hash = %v", m)
+	}
+	var audited testUser
+	if err := rp.HGetAll("user:1").ScanStruct(&audited); err != nil {
+		t.Fatal(err)
+	}
+	if audited.TestAudit == nil || audited.Source != "import" {
+		t.Errorf("ScanStruct embedded pointer = %+v", audited.TestAudit)
+	}
+
+	if err := rp.HSetStruct("user:1", struct{ C chan int }{}); err == nil {
+		t.Error("unsupported field type accepted")
+	}
+}
@@ -0,0 +1,168 @@
+package mredis
+
+import (
+	"context"
+	"github.com/gomodule/redigo/redis"
+	"time"
+)
+
+/*
+	Tx MULTI/EXEC 事务, 命令方法与 Pipe 相同, 返回的 Future 在 EXEC 之后得到结果
+*/
+type Tx struct {
+	futureCmdable
+	ctx    context.Context
+	conn   redis.Conn
+	multi  bool // 已经发送 MULTI
+	watch  bool // Watch 中, MULTI 之前的只读命令立即执行
+	queued []*Future
+	err    error // Send 失败后之后的命令不再发送
+}
+
+func newTx(ctx context.Context, conn redis.Conn) *Tx {
+	tx := &Tx{ctx: ctx, conn: conn}
+	tx.futureCmdable = tx.queue
+	return tx
+}
+
+// 第一条命令之前发送 MULTI, 命令只写入缓冲区, EXEC 时一起 Flush
+func (tx *Tx) queue(cmd string, args ...interface{}) *Future {
+	f := &Future{cmd: cmd, args: args}
+	if tx.err != nil {
+		f.resolve(nil, tx.err)
+		return f
+	}
+
+	if tx.watch && !tx.multi && isReadOnly(cmd) {
+		f.resolve(doContext(tx.conn, tx.ctx, cmd, args...))
+		return f
+	}
+
+	if !tx.multi {
+		if tx.err = tx.conn.Send("MULTI"); tx.err != nil {
+			f.resolve(nil, tx.err)
+			return f
+		}
+		tx.multi = true
+	}
+
+	if tx.err = tx.conn.Send(cmd, args...); tx.err != nil {
+		f.resolve(nil, tx.err)
+		return f
+	}
+	tx.queued = append(tx.queued, f)
+	return f
+}
+
+/*
+	rp.Tx(func(tx *Tx) error {
+		tx.HIncBy("account:1", "balance", -10)
+		tx.HIncBy("account:2", "balance", 10)
+		return nil
+	})
+
+	fn 返回错误时发送 DISCARD 并返回该错误; 命令排队失败(如参数个数错误)时 EXEC 被拒绝,
+	返回 EXECABORT 错误; EXEC 中单条命令的执行错误只保存在对应的 Reply 中
+*/
+func (rp *RedisPool) Tx(fn func(tx *Tx) error) error {
+	return rp.TxCtx(context.Background(), fn)
+}
+
+// ctx 作用于取连接和读取结果
+func (rp *RedisPool) TxCtx(ctx context.Context, fn func(tx *Tx) error) error {
+	conn, err := rp.getConnCtx(ctx)
+	if err != nil {
+		rp.stats.observe(0, err)
+		return err
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	err = runTx(newTx(ctx, conn), fn)
+	rp.stats.observe(time.Since(start), err)
+	return err
+}
+
+func runTx(tx *Tx, fn func(tx *Tx) error) error {
+	if err := fn(tx); err != nil {
+		tx.discard()
+		return err
+	}
+	if tx.err != nil {
+		resolveAll(tx.queued, tx.err)
+		return tx.err
+	}
+
+	return tx.exec()
+}
+
+func (tx *Tx) discard() {
+	if !tx.multi {
+		return
+	}
+
+	tx.conn.Send("DISCARD")
+	tx.conn.Do("") // 读取 MULTI、QUEUED 和 DISCARD 的回复
+	tx.multi = false
+}
+
+func (tx *Tx) exec() error {
+	if !tx.multi {
+		return nil
+	}
+	tx.multi = false
+
+	if err := tx.conn.Send("EXEC"); err != nil {
+		resolveAll(tx.queued, err)
+		return err
+	}
+	if err := tx.conn.Flush(); err != nil {
+		resolveAll(tx.queued, err)
+		return err
+	}
+
+	// MULTI 的 OK
+	if _, err := receiveContext(tx.conn, tx.ctx); isConnError(err) {
+		resolveAll(tx.queued, err)
+		return err
+	}
+
+	// 每条命令的 QUEUED, 排队失败时保存错误
+	queueErrs := make([]error, len(tx.queued))
+	for i := range tx.queued {
+		_, err := receiveContext(tx.conn, tx.ctx)
+		if isConnError(err) {
+			resolveAll(tx.queued, err)
+			return err
+		}
+		queueErrs[i] = err
+	}
+
+	values, err := redis.Values(receiveContext(tx.conn, tx.ctx))
+	if err == redis.ErrNil {
+		err = ErrTxConflict // WATCH 的 key 被修改
+	}
+	if err != nil {
+		for i, f := range tx.queued {
+			if queueErrs[i] != nil {
+				f.resolve(nil, queueErrs[i])
+			} else {
+				f.resolve(nil, err)
+			}
+		}
+		return err
+	}
+
+	for i, f := range tx.queued {
+		if i >= len(values) {
+			f.resolve(nil, redis.ErrNil)
+			continue
+		}
+		if e, ok := values[i].(redis.Error); ok {
+			f.resolve(nil, e)
+			continue
+		}
+		f.resolve(values[i], nil)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTx 单连接的 MULTI/EXEC, 记录执行过的命令
+type fakeTx struct {
+	mu     sync.Mutex
+	multi  bool
+	queued [][]string
+	log    []string
+	data   map[string]string
+
+	conflicts int // 之后多少次 EXEC 返回 nil, 模拟 WATCH 的 key 被修改
+}
+
+func (f *fakeTx) handle(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.log = append(f.log, args[0])
+	switch args[0] {
+	case "MULTI":
+		f.multi = true
+		return "+OK\r\n"
+	case "DISCARD":
+		f.multi, f.queued = false, nil
+		return "+OK\r\n"
+	case "WATCH", "UNWATCH":
+		return "+OK\r\n"
+	case "EXEC":
+		if f.conflicts > 0 {
+			f.conflicts--
+			f.multi, f.queued = false, nil
+			return "*-1\r\n"
+		}
+		out := make([]string, 0, len(f.queued))
+		for _, q := range f.queued {
+			out = append(out, f.run(q))
+		}
+		f.multi, f.queued = false, nil
+		return array(out...)
+	}
+
+	if f.multi {
+		f.queued = append(f.queued, args)
+		return "+QUEUED\r\n"
+	}
+	return f.run(args)
+}
+
+func (f *fakeTx) run(args []string) string {
+	switch args[0] {
+	case "SET":
+		f.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "INCR":
+		return "-ERR value is not an integer or out of range\r\n"
+	}
+	return "+OK\r\n"
+}
+
+func (f *fakeTx) commands() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s := strings.Join(f.log, " ")
+	f.log = nil
+	return s
+}
+
+func TestTx(t *testing.T) {
+	fake := &fakeTx{data: make(map[string]string)}
+	srv := newFakeRedis(t, fake.handle)
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(), WithHealthCheck(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	var set, incr, get *Future
+	err = rp.Tx(func(tx *Tx) error {
+		set = tx.Set("k", "v")
+		incr = tx.Incr("k")
+		get = tx.Get("k")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.commands(); got != "MULTI SET INCR GET EXEC" {
+		t.Errorf("commands = %q", got)
+	}
+	if !set.Reply().IsOK() {
+		t.Errorf("Set = %v", set.Reply().Error())
+	}
+	if incr.Reply().Error() == nil {
+		t.Error("Incr should fail")
+	}
+	if v, err := get.Reply().String(); err != nil || v != "v" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+
+	abort := errors.New("abort")
+	err = rp.Tx(func(tx *Tx) error {
+		set = tx.Set("k", "other")
+		return abort
+	})
+	if err != abort {
+		t.Errorf("Tx = %v, want abort", err)
+	}
+	if got := fake.commands(); got != "MULTI SET DISCARD" {
+		t.Errorf("commands = %q", got)
+	}
+	if set.Reply().Error() != ErrNotExecuted || fake.data["k"] != "v" {
+		t.Error("discarded command was executed")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	fake := &fakeTx{data: map[string]string{"stock": "5"}, conflicts: 1}
+	srv := newFakeRedis(t, fake.handle)
+	defer srv.Close()
+
+	rp, err := NewRedisPoolWithOptions(srv.Addr().String(), WithHealthCheck(0), WithWatchRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	calls := 0
+	decr := func(tx *Tx) error {
+		calls++
+		n, err := tx.Get("stock").Reply().Int()
+		if err != nil {
+			return err
+		}
+		tx.Set("stock", n-1)
+		return nil
+	}
+
+	if err := rp.Watch(context.Background(), []string{"stock"}, decr); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || fake.data["stock"] != "4" {
+		t.Errorf("calls = %d, stock = %s", calls, fake.data["stock"])
+	}
+	if got := fake.commands(); !strings.HasPrefix(got, "WATCH GET MULTI SET EXEC") {
+		t.Errorf("commands = %q", got)
+	}
+
+	fake.mu.Lock()
+	fake.conflicts = 10
+	fake.mu.Unlock()
+	calls = 0
+	if err := rp.Watch(context.Background(), []string{"stock"}, decr); err != ErrTxConflict {
+		t.Errorf("Watch = %v, want ErrTxConflict", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
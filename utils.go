@@ -1,5 +1,10 @@
 package mredis
 
+import (
+	redigo "github.com/gomodule/redigo/redis"
+	"sync"
+)
+
 // 生成redis分页
 func buildRange(cur, ps int) (int, int) {
 	begin := 0
@@ -10,3 +15,91 @@ func buildRange(cur, ps int) (int, int) {
 
 	return begin, end
 }
+
+
+// 按 key 分组后的参数, idx 为分组前的位置(以 step 个参数为一组计数)
+type argGroup struct {
+	target interface{}
+	idx    []int
+	args   []interface{}
+}
+
+// 每 step 个参数为一组, 按第一个参数(key)经 targetOf 得到的目标分组, 组内保持原有顺序
+func groupArgs(args []interface{}, step int, targetOf func(key string) interface{}) []*argGroup {
+	byTarget := make(map[interface{}]*argGroup)
+	groups := make([]*argGroup, 0, 4)
+	for i := 0; i+step <= len(args); i += step {
+		target := targetOf(keyString(args[i]))
+		g, ok := byTarget[target]
+		if !ok {
+			g = &argGroup{target: target}
+			byTarget[target] = g
+			groups = append(groups, g)
+		}
+
+		g.idx = append(g.idx, i/step)
+		g.args = append(g.args, args[i:i+step]...)
+	}
+
+	return groups
+}
+
+// 每组并发执行一次 exec, 返回与 groups 对应的结果
+func scatter(groups []*argGroup, exec func(g *argGroup) (interface{}, error)) ([]interface{}, error) {
+	replies := make([]interface{}, len(groups))
+	if len(groups) == 1 {
+		var err error
+		replies[0], err = exec(groups[0])
+		return replies, err
+	}
+
+	errs := make([]error, len(groups))
+	var wg sync.WaitGroup
+	for i, g := range groups {
+		wg.Add(1)
+		go func(i int, g *argGroup) {
+			defer wg.Done()
+			replies[i], errs[i] = exec(g)
+		}(i, g)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return replies, nil
+}
+
+// MGET 之类各组返回的数组按分组前的位置合并
+func gatherValues(n int, groups []*argGroup, replies []interface{}) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	for i, g := range groups {
+		vs, err := redigo.Values(replies[i], nil)
+		if err != nil {
+			return nil, err
+		}
+		for j, idx := range g.idx {
+			if j < len(vs) {
+				values[idx] = vs[j]
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// DEL 之类各组返回的数量求和
+func sumInts(replies []interface{}) (int, error) {
+	total := 0
+	for _, r := range replies {
+		n, err := redigo.Int(r, nil)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+
+	return total, nil
+}
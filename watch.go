@@ -0,0 +1,94 @@
+package mredis
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	WatchRetriesDefault = 3
+	WatchBackoffDefault = 10 * time.Millisecond
+)
+
+var ErrTxConflict = errors.New("mredis: transaction aborted, watched key changed")
+
+// Watch 冲突后最多重试 retries 次, 第一次重试前等待 backoff, 之后每次翻倍
+func WithWatchRetry(retries int, backoff time.Duration) Option {
+	return func(opt *poolOption) {
+		opt.WatchRetries = retries
+		opt.WatchBackoff = backoff
+	}
+}
+
+func (rp *RedisPool) watchRetry() (int, time.Duration) {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+
+	if rp.opt == nil {
+		return WatchRetriesDefault, WatchBackoffDefault
+	}
+	return rp.opt.WatchRetries, rp.opt.WatchBackoff
+}
+
+/*
+	Watch 乐观锁, 在同一条连接上 WATCH keys 后执行 fn:
+	fn 中第一条写命令之前的只读命令(GET, HGET 等)立即执行, Future 马上可以取结果,
+	第一条写命令开始 MULTI, 之后的命令都在事务中, fn 返回后 EXEC
+
+	rp.Watch(ctx, []string{"stock:1"}, func(tx *Tx) error {
+		n, err := tx.Get("stock:1").Reply().Int()
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return ErrSoldOut
+		}
+		tx.Set("stock:1", n-1)
+		return nil
+	})
+
+	key 被其他客户端修改导致 EXEC 失败时重新执行 fn, 重试次数用完返回 ErrTxConflict,
+	fn 可能被执行多次, 不要在 fn 中修改外部状态
+*/
+func (rp *RedisPool) Watch(ctx context.Context, keys []string, fn func(tx *Tx) error) error {
+	retries, backoff := rp.watchRetry()
+	for attempt := 0; ; attempt++ {
+		err := rp.watchOnce(ctx, keys, fn)
+		if err != ErrTxConflict || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff << uint(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// 连接归还时 redigo 会发送 UNWATCH
+func (rp *RedisPool) watchOnce(ctx context.Context, keys []string, fn func(tx *Tx) error) error {
+	conn, err := rp.getConnCtx(ctx)
+	if err != nil {
+		rp.stats.observe(0, err)
+		return err
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	if _, err := doContext(conn, ctx, "WATCH", args...); err != nil {
+		rp.stats.observe(time.Since(start), err)
+		return err
+	}
+
+	tx := newTx(ctx, conn)
+	tx.watch = true
+	err = runTx(tx, fn)
+	rp.stats.observe(time.Since(start), err)
+	return err
+}
@@ -1,18 +1,22 @@
 package mredis
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	redigo "github.com/gomodule/redigo/redis"
+	"reflect"
 )
 
 /*   O(log(N))
 	args: 必须是key, score,id[,score,id]的列表
 */
-func (rp *RedisPool) ZAdd(args ...interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZAdd(args ...interface{}) (e error) {
+	return c.ZAddCtx(context.Background(), args...)
+}
 
-	_, e = conn.Do("ZADD", args...)
+func (c cmdable) ZAddCtx(ctx context.Context, args ...interface{}) (e error) {
+	_, e = c(ctx, "ZADD", args...)
 	return e
 }
 
@@ -20,64 +24,71 @@ func (rp *RedisPool) ZAdd(args ...interface{}) (e error) {
 	args: 必须是key, score,id[,score,id]的列表
 	n: 影响的行数
 */
-func (rp *RedisPool) ZAddWithReturn(args ...interface{}) (int64, error) {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) ZAddWithReturn(args ...interface{}) (int64, error) {
+	return c.ZAddWithReturnCtx(context.Background(), args...)
+}
 
-	return redigo.Int64(con.Do("ZADD", args...))
+func (c cmdable) ZAddWithReturnCtx(ctx context.Context, args ...interface{}) (int64, error) {
+	return redigo.Int64(c(ctx, "ZADD", args...))
 }
 
 // O(log(N))
-func (rp *RedisPool) ZCount(key interface{}, min, max float64) (count int64, e error) {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) ZCount(key interface{}, min, max float64) (count int64, e error) {
+	return c.ZCountCtx(context.Background(), key, min, max)
+}
 
-	return redigo.Int64(con.Do("ZCOUNT", key, min, max))
+func (c cmdable) ZCountCtx(ctx context.Context, key interface{}, min, max float64) (count int64, e error) {
+	return redigo.Int64(c(ctx, "ZCOUNT", key, min, max))
 }
 
 // O(1)
-func (rp *RedisPool) ZCard(key interface{}) (num int64, e error) {
-	scon := rp.getConn()
-	defer scon.Close()
+func (c cmdable) ZCard(key interface{}) (num int64, e error) {
+	return c.ZCardCtx(context.Background(), key)
+}
 
-	return redigo.Int64(scon.Do("ZCARD", key))
+func (c cmdable) ZCardCtx(ctx context.Context, key interface{}) (num int64, e error) {
+	return redigo.Int64(c(ctx, "ZCARD", key))
 }
 
 // O(log(N))
-func (rp *RedisPool) ZRank(key interface{}, member interface{}, asc bool) (rank int64, e error) {
-	con := rp.getConn()
-	defer con.Close()
+func (c cmdable) ZRank(key interface{}, member interface{}, asc bool) (rank int64, e error) {
+	return c.ZRankCtx(context.Background(), key, member, asc)
+}
 
+func (c cmdable) ZRankCtx(ctx context.Context, key interface{}, member interface{}, asc bool) (rank int64, e error) {
 	if !asc {
-		return redigo.Int64(con.Do("ZREVRANK", key, member))
+		return redigo.Int64(c(ctx, "ZREVRANK", key, member))
 	}
 
-	return redigo.Int64(con.Do("ZRANK", key, member))
+	return redigo.Int64(c(ctx, "ZRANK", key, member))
 }
 
 // O(log(N))
-func (rp *RedisPool) ZIncBy(key interface{}, increment interface{}, member interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZIncBy(key interface{}, increment interface{}, member interface{}) (e error) {
+	return c.ZIncByCtx(context.Background(), key, increment, member)
+}
 
-	_, e = conn.Do("ZINCRBY", key, increment, member)
+func (c cmdable) ZIncByCtx(ctx context.Context, key interface{}, increment interface{}, member interface{}) (e error) {
+	_, e = c(ctx, "ZINCRBY", key, increment, member)
 	return
 }
 
 // O(log(N))
-func (rp *RedisPool) ZIncByWithReturn(key interface{}, increment interface{}, member interface{}) (score int64, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZIncByWithReturn(key interface{}, increment interface{}, member interface{}) (score int64, e error) {
+	return c.ZIncByWithReturnCtx(context.Background(), key, increment, member)
+}
 
-	return redigo.Int64(conn.Do("ZINCRBY", key, increment, member))
+func (c cmdable) ZIncByWithReturnCtx(ctx context.Context, key interface{}, increment interface{}, member interface{}) (score int64, e error) {
+	return redigo.Int64(c(ctx, "ZINCRBY", key, increment, member))
 }
 
 // O(1)
-func (rp *RedisPool) ZScore(key interface{}, item interface{}) (score int64, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZScore(key interface{}, item interface{}) (score int64, e error) {
+	return c.ZScoreCtx(context.Background(), key, item)
+}
 
-	return redigo.Int64(conn.Do("ZSCORE", key, item))
+func (c cmdable) ZScoreCtx(ctx context.Context, key interface{}, item interface{}) (score int64, e error) {
+	return redigo.Int64(c(ctx, "ZSCORE", key, item))
 }
 
 ////批量获取有序集合的元素的得分
@@ -105,11 +116,12 @@ func (rp *RedisPool) ZScore(key interface{}, item interface{}) (score int64, e e
 //}
 
 //ZIsMember判断是否是有序集合的成员
-func (rp *RedisPool) ZIsMember(key interface{}, item interface{}) (isMember bool, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZIsMember(key interface{}, item interface{}) (isMember bool, e error) {
+	return c.ZIsMemberCtx(context.Background(), key, item)
+}
 
-	_, e = redigo.Float64(conn.Do("ZSCORE", key, item))
+func (c cmdable) ZIsMemberCtx(ctx context.Context, key interface{}, item interface{}) (isMember bool, e error) {
+	_, e = redigo.Float64(c(ctx, "ZSCORE", key, item))
 	switch e {
 	case nil:
 		return true, nil
@@ -152,182 +164,315 @@ func (rp *RedisPool) ZIsMember(key interface{}, item interface{}) (isMember bool
 	args: 必须是key,id2,id2,id3的列表
 	   n: 每条命令影响的行数
 */
-func (rp *RedisPool) ZRem(args ...interface{}) (e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZRem(args ...interface{}) (e error) {
+	return c.ZRemCtx(context.Background(), args...)
+}
 
-	_, e = conn.Do("ZREM", args...)
+func (c cmdable) ZRemCtx(ctx context.Context, args ...interface{}) (e error) {
+	_, e = c(ctx, "ZREM", args...)
 	return
 }
 
-func (rp *RedisPool) ZRemWithReturn(args ...interface{}) (n int, e error) {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZRemWithReturn(args ...interface{}) (n int, e error) {
+	return c.ZRemWithReturnCtx(context.Background(), args...)
+}
 
-	return redigo.Int(conn.Do("ZREM", args...))
+func (c cmdable) ZRemWithReturnCtx(ctx context.Context, args ...interface{}) (n int, e error) {
+	return redigo.Int(c(ctx, "ZREM", args...))
 }
 
 // O(log(N)+M
 // 默认从小到大-[start, end]
-func (rp *RedisPool) ZRange(key interface{}, start, end int) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZRange(key interface{}, start, end int) *Reply {
+	return c.ZRangeCtx(context.Background(), key, start, end)
+}
 
-	return reply(conn.Do("ZRANGE", key, start, end))
+func (c cmdable) ZRangeCtx(ctx context.Context, key interface{}, start, end int) *Reply {
+	return reply(c(ctx, "ZRANGE", key, start, end))
 }
 
 // 默认从大到小-[start, end]
-func (rp *RedisPool) ZRevRange(key interface{}, start, end int) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZRevRange(key interface{}, start, end int) *Reply {
+	return c.ZRevRangeCtx(context.Background(), key, start, end)
+}
+
+func (c cmdable) ZRevRangeCtx(ctx context.Context, key interface{}, start, end int) *Reply {
+	return reply(c(ctx, "ZREVRANGE", key, start, end))
+}
 
-	return reply(conn.Do("ZREVRANGE", key, start, end))
+func (c cmdable) ZRangePS(key interface{}, cur int, ps int) *Reply {
+	return c.ZRangePSCtx(context.Background(), key, cur, ps)
 }
 
-func (rp *RedisPool) ZRangePS(key interface{}, cur int, ps int) *Reply {
+func (c cmdable) ZRangePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply {
 	start, end := buildRange(cur, ps)
-	return rp.ZRange(key, start, end)
+	return c.ZRangeCtx(ctx, key, start, end)
+}
+
+func (c cmdable) ZRevRangePS(key interface{}, cur int, ps int) *Reply {
+	return c.ZRevRangePSCtx(context.Background(), key, cur, ps)
 }
 
-func (rp *RedisPool) ZRevRangePS(key interface{}, cur int, ps int) *Reply {
+func (c cmdable) ZRevRangePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply {
 	start, end := buildRange(cur, ps)
-	return rp.ZRevRange(key, start, end)
+	return c.ZRevRangeCtx(ctx, key, start, end)
 }
 
 //
-func (rp *RedisPool) ZRangeWithScore(key interface{}, start, end int) *Reply {
-	return rp.zRangeWithScore(key, start, end, true)
+func (c cmdable) ZRangeWithScore(key interface{}, start, end int) *Reply {
+	return c.zRangeWithScore(context.Background(), key, start, end, true)
+}
+
+func (c cmdable) ZRangeWithScoreCtx(ctx context.Context, key interface{}, start, end int) *Reply {
+	return c.zRangeWithScore(ctx, key, start, end, true)
+}
+
+func (c cmdable) ZRevRangeWithScore(key interface{}, start, end int) *Reply {
+	return c.zRangeWithScore(context.Background(), key, start, end, false)
+}
+
+func (c cmdable) ZRevRangeWithScoreCtx(ctx context.Context, key interface{}, start, end int) *Reply {
+	return c.zRangeWithScore(ctx, key, start, end, false)
+}
+
+func (c cmdable) ZRangeWithScorePS(key interface{}, cur int, ps int) *Reply {
+	return c.zRangeWithScorePS(context.Background(), key, cur, ps, true)
 }
 
-func (rp *RedisPool) ZRevRangeWithScore(key interface{}, start, end int) *Reply {
-	return rp.zRangeWithScore(key, start, end, false)
+func (c cmdable) ZRangeWithScorePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply {
+	return c.zRangeWithScorePS(ctx, key, cur, ps, true)
 }
 
-func (rp *RedisPool) ZRangeWithScorePS(key interface{}, cur int, ps int) *Reply {
-	return rp.zRangeWithScorePS(key, cur, ps, true)
+func (c cmdable) ZRevRangeWithScorePS(key interface{}, cur int, ps int) *Reply {
+	return c.zRangeWithScorePS(context.Background(), key, cur, ps, false)
 }
 
-func (rp *RedisPool) ZRevRangeWithScorePS(key interface{}, cur int, ps int) *Reply {
-	return rp.zRangeWithScorePS(key, cur, ps, false)
+func (c cmdable) ZRevRangeWithScorePSCtx(ctx context.Context, key interface{}, cur int, ps int) *Reply {
+	return c.zRangeWithScorePS(ctx, key, cur, ps, false)
 }
 
 // 从小到大-[min, max]
-func (rp *RedisPool) ZRangeByScore(key interface{}, min, max interface{}, limit int) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZRangeByScore(key interface{}, min, max interface{}, limit int) *Reply {
+	return c.ZRangeByScoreCtx(context.Background(), key, min, max, limit)
+}
 
+func (c cmdable) ZRangeByScoreCtx(ctx context.Context, key interface{}, min, max interface{}, limit int) *Reply {
 	if limit > 0 {
-		return reply(conn.Do("ZRANGEBYSCORE", key, min, max, "LIMIT", 0, limit))
+		return reply(c(ctx, "ZRANGEBYSCORE", key, min, max, "LIMIT", 0, limit))
 	}
 
-	return reply(conn.Do("ZRANGEBYSCORE", key, min, max))
+	return reply(c(ctx, "ZRANGEBYSCORE", key, min, max))
 }
 
-func (rp *RedisPool) ZRevRangeByScore(key interface{}, min, max interface{}, limit int) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZRevRangeByScore(key interface{}, min, max interface{}, limit int) *Reply {
+	return c.ZRevRangeByScoreCtx(context.Background(), key, min, max, limit)
+}
 
+func (c cmdable) ZRevRangeByScoreCtx(ctx context.Context, key interface{}, min, max interface{}, limit int) *Reply {
 	if limit > 0 {
-		return reply(conn.Do("ZREVRANGEBYSCORE", key, max, min, "LIMIT", 0, limit))
+		return reply(c(ctx, "ZREVRANGEBYSCORE", key, max, min, "LIMIT", 0, limit))
 	}
 
-	return reply(conn.Do("ZREVRANGEBYSCORE", key, max, min))
+	return reply(c(ctx, "ZREVRANGEBYSCORE", key, max, min))
+}
+
+func (c cmdable) ZRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Reply {
+	return c.ZRangeByScoreWithScoreCtx(context.Background(), key, min, max, limit)
 }
 
-func (rp *RedisPool) ZRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Reply {
+func (c cmdable) ZRangeByScoreWithScoreCtx(ctx context.Context, key interface{}, min, max int64, limit int) *Reply {
 	if limit > 0 {
-		rp.zRangeByScoreWithScoreLimit(key, min, max, limit, true)
+		return c.zRangeByScoreWithScoreLimit(ctx, key, min, max, limit, true)
 	}
-	return rp.zRangeByScoreWithScoreNoLimit(key, min, max, true)
+	return c.zRangeByScoreWithScoreNoLimit(ctx, key, min, max, true)
+}
+
+func (c cmdable) ZRevRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Reply {
+	return c.ZRevRangeByScoreWithScoreCtx(context.Background(), key, min, max, limit)
 }
 
-func (rp *RedisPool) ZRevRangeByScoreWithScore(key interface{}, min, max int64, limit int) *Reply {
+func (c cmdable) ZRevRangeByScoreWithScoreCtx(ctx context.Context, key interface{}, min, max int64, limit int) *Reply {
 	if limit > 0 {
-		return rp.zRangeByScoreWithScoreLimit(key, min, max, limit, false)
+		return c.zRangeByScoreWithScoreLimit(ctx, key, min, max, limit, false)
 	}
-	return rp.zRangeByScoreWithScoreNoLimit(key, min, max, false)
+	return c.zRangeByScoreWithScoreNoLimit(ctx, key, min, max, false)
 }
 
-func (rp *RedisPool) ZRangeByScoreWithScorePS(key interface{}, min, max int64, cur, ps int) *Reply {
-	return rp.zRangeByScoreWithScorePS(key, min, max, cur, ps, true)
+func (c cmdable) ZRangeByScoreWithScorePS(key interface{}, min, max int64, cur, ps int) *Reply {
+	return c.zRangeByScoreWithScorePS(context.Background(), key, min, max, cur, ps, true)
+}
+
+func (c cmdable) ZRangeByScoreWithScorePSCtx(ctx context.Context, key interface{}, min, max int64, cur, ps int) *Reply {
+	return c.zRangeByScoreWithScorePS(ctx, key, min, max, cur, ps, true)
 }
 
 /*
 根据score 获取有序集 ZREVRANGEBYSCORE min <=score < max  按照score 从大到小排序, ps 获取条数
 */
-func (rp *RedisPool) ZRevRangeByScoreWithScorePS(key interface{}, min, max int64, cur, ps int) *Reply {
-	return rp.zRangeByScoreWithScorePS(key, min, max, cur, ps, false)
+func (c cmdable) ZRevRangeByScoreWithScorePS(key interface{}, min, max int64, cur, ps int) *Reply {
+	return c.zRangeByScoreWithScorePS(context.Background(), key, min, max, cur, ps, false)
+}
+
+func (c cmdable) ZRevRangeByScoreWithScorePSCtx(ctx context.Context, key interface{}, min, max int64, cur, ps int) *Reply {
+	return c.zRangeByScoreWithScorePS(ctx, key, min, max, cur, ps, false)
 }
 
 //分页获取带积分的SortedSet值
-func (rp *RedisPool) zRangeWithScorePS(key interface{}, cur int, ps int, asc bool) *Reply {
+func (c cmdable) zRangeWithScorePS(ctx context.Context, key interface{}, cur int, ps int, asc bool) *Reply {
 	if ps > 100 {
 		ps = 100
 	}
 
 	start, end := buildRange(cur, ps)
-	return rp.zRangeWithScore(key, start, end, asc)
+	return c.zRangeWithScore(ctx, key, start, end, asc)
 }
 
-func (rp *RedisPool) zRangeWithScore(key interface{}, start, end int, asc bool) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
-
+func (c cmdable) zRangeWithScore(ctx context.Context, key interface{}, start, end int, asc bool) *Reply {
 	if !asc {
-		return reply(conn.Do("ZREVRANGE", key, start, end, "WITHSCORES"))
+		return reply(c(ctx, "ZREVRANGE", key, start, end, "WITHSCORES"))
 	}
 
-	return reply(conn.Do("ZRANGE", key, start, end, "WITHSCORES"))
+	return reply(c(ctx, "ZRANGE", key, start, end, "WITHSCORES"))
 }
 
 // min <= score < max
-func (rp *RedisPool) zRangeByScoreWithScoreNoLimit(key interface{}, min, max interface{}, asc bool) *Reply {
-	conn := rp.getConn()
-	defer conn.Close()
-
+func (c cmdable) zRangeByScoreWithScoreNoLimit(ctx context.Context, key interface{}, min, max interface{}, asc bool) *Reply {
 	s := fmt.Sprintf("(%v", max)
 	if asc {
-		return reply(conn.Do("ZRANGEBYSCORE", key, min, s, "WITHSCORES"))
+		return reply(c(ctx, "ZRANGEBYSCORE", key, min, s, "WITHSCORES"))
 	}
 
-	return reply(conn.Do("ZREVRANGEBYSCORE", key, s, min, "WITHSCORES"))
+	return reply(c(ctx, "ZREVRANGEBYSCORE", key, s, min, "WITHSCORES"))
 }
 
 // min <= score < max
-func (rp *RedisPool) zRangeByScoreWithScoreLimit(key interface{}, min, max interface{}, limit int, asc bool) *Reply {
-	con := rp.getConn()
-	defer con.Close()
-
+func (c cmdable) zRangeByScoreWithScoreLimit(ctx context.Context, key interface{}, min, max interface{}, limit int, asc bool) *Reply {
 	s := fmt.Sprintf("(%v", max)
 	if asc {
-		return reply(con.Do("ZRANGEBYSCORE", key, min, s, "WITHSCORES", "LIMIT", 0, limit))
+		return reply(c(ctx, "ZRANGEBYSCORE", key, min, s, "WITHSCORES", "LIMIT", 0, limit))
 	}
 
-	return reply(con.Do("ZREVRANGEBYSCORE", key, s, min, "WITHSCORES", "LIMIT", 0, limit))
+	return reply(c(ctx, "ZREVRANGEBYSCORE", key, s, min, "WITHSCORES", "LIMIT", 0, limit))
 }
 
 // min <= score < max
-func (rp *RedisPool) zRangeByScoreWithScorePS(key interface{}, min, max interface{}, cur, ps int, asc bool) *Reply {
+func (c cmdable) zRangeByScoreWithScorePS(ctx context.Context, key interface{}, min, max interface{}, cur, ps int, asc bool) *Reply {
 	start, end := buildRange(cur, ps)
-	con := rp.getConn()
-	defer con.Close()
 
 	s := fmt.Sprintf("(%v", max)
 	if asc {
-		return reply(con.Do("ZRANGEBYSCORE", key, min, s, "WITHSCORES", "LIMIT", start, end))
+		return reply(c(ctx, "ZRANGEBYSCORE", key, min, s, "WITHSCORES", "LIMIT", start, end))
 	}
 
-	return reply(con.Do("ZREVRANGEBYSCORE", key, s, min, "WITHSCORES", "LIMIT", start, end))
+	return reply(c(ctx, "ZREVRANGEBYSCORE", key, s, min, "WITHSCORES", "LIMIT", start, end))
 }
 
 /*
 移除有序集中元素[min,max]
 */
-func (rp *RedisPool) ZRemRangeByScore(key interface{}, min, max int64) error {
-	conn := rp.getConn()
-	defer conn.Close()
+func (c cmdable) ZRemRangeByScore(key interface{}, min, max int64) error {
+	return c.ZRemRangeByScoreCtx(context.Background(), key, min, max)
+}
 
-	_, e := conn.Do("ZREMRANGEBYSCORE", key, min, max)
+func (c cmdable) ZRemRangeByScoreCtx(ctx context.Context, key interface{}, min, max int64) error {
+	_, e := c(ctx, "ZREMRANGEBYSCORE", key, min, max)
 	return e
 }
 
+/*
+	--------------- WITHSCORES 结果 -----------------------
+*/
+
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// 按 member, score 交替的 WITHSCORES 结果转换, 顺序不变; RESP3 下的 [member, score] 二元组同样支持
+func (r *Reply) ZMembers() ([]ZMember, error) {
+	members := make([]ZMember, 0, 8)
+	err := r.zMembers(func(member []byte, score float64) error {
+		members = append(members, ZMember{Member: string(member), Score: score})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+/*
+	ZMembersDecode 通过 codec 解码 member, members 为 *[]T, 返回与之一一对应的 score;
+	解码规则同 Reply.Decode, codec 为 nil 时使用 JSONCodec, 出错时 members 保持不变
+
+	var users []User
+	scores, err := rp.ZRevRangeWithScore("rank", 0, 9).ZMembersDecode(mredis.JSONCodec, &users)
+*/
+func (r *Reply) ZMembersDecode(codec Codec, members interface{}) ([]float64, error) {
+	rv := reflect.ValueOf(members)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("mredis: ZMembersDecode members must be a pointer to slice, got %T", members)
+	}
+	if codec == nil {
+		codec = JSONCodec
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	result := reflect.MakeSlice(slice.Type(), 0, 8)
+	scores := make([]float64, 0, 8)
+	err := r.zMembers(func(member []byte, score float64) error {
+		elem, err := decodeElem(codec, elemType, member)
+		if err != nil {
+			return err
+		}
+		result = reflect.Append(result, elem)
+		scores = append(scores, score)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slice.Set(result)
+	return scores, nil
+}
+
+func (r *Reply) zMembers(fn func(member []byte, score float64) error) error {
+	values, err := redigo.Values(r.Raw, r.Err)
+	if err != nil {
+		return err
+	}
+
+	// RESP3: [[member, score], ...]
+	if len(values) > 0 {
+		if _, ok := values[0].([]interface{}); ok {
+			flat := make([]interface{}, 0, 2*len(values))
+			for _, v := range values {
+				pair, ok := v.([]interface{})
+				if !ok || len(pair) != 2 {
+					return errors.New("mredis: ZMembers expects [member, score] pairs")
+				}
+				flat = append(flat, pair...)
+			}
+			values = flat
+		}
+	}
+
+	if len(values)%2 != 0 {
+		return errors.New("mredis: ZMembers expects even number of values in result")
+	}
+	for i := 0; i < len(values); i += 2 {
+		member, err := redigo.Bytes(values[i], nil)
+		if err != nil {
+			return err
+		}
+		score, err := redigo.Float64(values[i+1], nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(member, score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
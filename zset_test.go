@@ -0,0 +1,107 @@
+package mredis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestZMembers(t *testing.T) {
+	flat := reply([]interface{}{[]byte("a"), []byte("1.5"), []byte("b"), []byte("-2")}, nil)
+	nested := reply([]interface{}{
+		[]interface{}{[]byte("a"), []byte("1.5")},
+		[]interface{}{[]byte("b"), []byte("-2")},
+	}, nil)
+
+	for name, r := range map[string]*Reply{"RESP2": flat, "RESP3": nested} {
+		members, err := r.ZMembers()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := []ZMember{{"a", 1.5}, {"b", -2}}
+		if len(members) != len(want) || members[0] != want[0] || members[1] != want[1] {
+			t.Errorf("%s: ZMembers = %v, want %v", name, members, want)
+		}
+	}
+
+	if _, err := reply([]interface{}{[]byte("a")}, nil).ZMembers(); err == nil {
+		t.Error("odd number of values accepted")
+	}
+	if members, err := reply([]interface{}{}, nil).ZMembers(); err != nil || len(members) != 0 {
+		t.Errorf("empty ZMembers = %v, %v", members, err)
+	}
+}
+
+func TestZMembersDecode(t *testing.T) {
+	type player struct {
+		Name string `json:"name"`
+	}
+
+	r := reply([]interface{}{
+		[]byte(`{"name":"ann"}`), []byte("30"),
+		[]byte(`{"name":"bob"}`), []byte("20"),
+	}, nil)
+
+	var players []player
+	scores, err := r.ZMembersDecode(JSONCodec, &players)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 2 || players[0].Name != "ann" || players[1].Name != "bob" {
+		t.Errorf("players = %v", players)
+	}
+	if len(scores) != 2 || scores[0] != 30 || scores[1] != 20 {
+		t.Errorf("scores = %v", scores)
+	}
+
+	if _, err := r.ZMembersDecode(JSONCodec, players); err == nil {
+		t.Error("non-pointer members accepted")
+	}
+
+	// codec 为 nil 时使用 JSON, 出错时不修改 members
+	var more []player
+	if _, err := r.ZMembersDecode(nil, &more); err != nil || len(more) != 2 {
+		t.Errorf("nil codec = %v, %v", more, err)
+	}
+	bad := reply([]interface{}{
+		[]byte(`{"name":"cat"}`), []byte("10"),
+		[]byte(`not json`), []byte("5"),
+	}, nil)
+	if _, err := bad.ZMembersDecode(JSONCodec, &more); err == nil || len(more) != 2 || more[0].Name != "ann" {
+		t.Errorf("failed decode left members = %v, %v", more, err)
+	}
+
+	data, err := ProtobufCodec.Marshal(wrapperspb.String("dan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []*wrapperspb.StringValue
+	pb := reply([]interface{}{data, []byte("1")}, nil)
+	if _, err := pb.ZMembersDecode(ProtobufCodec, &msgs); err != nil || len(msgs) != 1 || msgs[0].GetValue() != "dan" {
+		t.Errorf("protobuf ZMembersDecode = %v, %v", msgs, err)
+	}
+}
+
+// limit > 0 时只执行一次带 LIMIT 的查询
+func TestZRangeByScoreWithScoreLimit(t *testing.T) {
+	var calls []string
+	c := cmdable(func(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+		calls = append(calls, strings.TrimSpace(fmt.Sprintln(append([]interface{}{cmd}, args...)...)))
+		return []interface{}{}, nil
+	})
+
+	c.ZRangeByScoreWithScore("z", 1, 10, 5)
+	c.ZRevRangeByScoreWithScore("z", 1, 10, 5)
+	c.ZRangeByScoreWithScore("z", 1, 10, 0)
+	want := []string{
+		"ZRANGEBYSCORE z 1 (10 WITHSCORES LIMIT 0 5",
+		"ZREVRANGEBYSCORE z (10 1 WITHSCORES LIMIT 0 5",
+		"ZRANGEBYSCORE z 1 (10 WITHSCORES",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}